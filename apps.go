@@ -0,0 +1,118 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// AppMatcher matches windows of an app by window class, executable name
+// and/or a regular expression matching the window title. Fields left empty
+// match any window, but a matcher with no fields set matches nothing.
+type AppMatcher struct {
+	Class string `json:"class,omitempty"`
+	Exe   string `json:"exe,omitempty"`   // e.g. "vlc.exe"
+	Title string `json:"title,omitempty"` // e.g. "^Find and Replace$"
+
+	title *regexp.Regexp // compiled Title
+}
+
+func (m *AppMatcher) UnmarshalJSON(b []byte) error {
+	type plain AppMatcher
+	var v plain
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*m = AppMatcher(v)
+	if m.Title != "" {
+		re, err := regexp.Compile(m.Title)
+		if err != nil {
+			return fmt.Errorf("invalid title pattern %q: %w", m.Title, err)
+		}
+		m.title = re
+	}
+	return nil
+}
+
+func (m AppMatcher) String() string {
+	if m.Title != "" {
+		return fmt.Sprintf("class=%q,exe=%q,title=%q", m.Class, m.Exe, m.Title)
+	}
+	return fmt.Sprintf("class=%q,exe=%q", m.Class, m.Exe)
+}
+
+// matches reports whether the window is of the app. The title, which can
+// change and is the most expensive to check, is only checked last.
+func (m AppMatcher) matches(hwnd w32.HWND) bool {
+	if m.Class == "" && m.Exe == "" && m.title == nil {
+		return false
+	}
+	if m.Class != "" {
+		if c, ok := w32.GetClassName(hwnd); !ok || !strings.EqualFold(c, m.Class) {
+			return false
+		}
+	}
+	if m.Exe != "" && !strings.EqualFold(filepath.Base(windowExe(hwnd)), m.Exe) {
+		return false
+	}
+	if m.title != nil && !m.title.MatchString(w32.GetWindowText(hwnd)) {
+		return false
+	}
+	return true
+}
+
+// matchApp returns the first matcher matching the window.
+func matchApp(matchers []AppMatcher, hwnd w32.HWND) (AppMatcher, bool) {
+	for _, m := range matchers {
+		if m.matches(hwnd) {
+			return m, true
+		}
+	}
+	return AppMatcher{}, false
+}
+
+// windowExe returns the executable path of the process owning the window, or
+// an empty string if it cannot be determined.
+func windowExe(hwnd w32.HWND) string {
+	_, pid := w32.GetWindowThreadProcessId(hwnd)
+	h := w32.OpenProcess(w32ex.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if h == 0 {
+		return ""
+	}
+	defer w32.CloseHandle(h)
+	path, _ := w32ex.QueryFullProcessImageName(h)
+	return path
+}
+
+// isDpiUnaware reports whether the process owning the window is not DPI aware,
+// in which case Windows virtualizes its DPI.
+func isDpiUnaware(hwnd w32.HWND) bool {
+	_, pid := w32.GetWindowThreadProcessId(hwnd)
+	h := w32.OpenProcess(w32ex.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if h == 0 {
+		return false
+	}
+	defer w32.CloseHandle(h)
+	v, ok := w32ex.GetProcessDpiAwareness(h)
+	return ok && v == w32ex.PROCESS_DPI_UNAWARE
+}
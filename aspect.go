@@ -0,0 +1,60 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// AppAspectRatio is the aspect ratio the windows of an app are sized to.
+type AppAspectRatio struct {
+	App   AppMatcher `json:"app"`
+	Ratio string     `json:"ratio"` // e.g. "16:9"
+}
+
+// fitAspectRatio resizes the window to the largest size of the aspect ratio
+// configured for its app, or the global one, fitting the work area, centered.
+func fitAspectRatio(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	ratio := config.AspectRatio
+	for _, r := range config.AppAspectRatios {
+		if r.App.matches(hwnd) {
+			ratio = r.Ratio
+			break
+		}
+	}
+	rw, rh, _ := parseAspectRatio(ratio) // already checked by Config.validate
+	fmt.Printf("> fitting aspect ratio %s\n", ratio)
+	return resize(hwnd, func(disp, _ w32.RECT) w32.RECT {
+		w, h := disp.Width(), mulDivRound(disp.Width(), rh, rw)
+		if h > disp.Height() {
+			w, h = mulDivRound(disp.Height(), rw, rh), disp.Height()
+		}
+		return center(disp, w32.RECT{Right: w, Bottom: h})
+	})
+}
+
+// parseAspectRatio parses a "W:H" aspect ratio.
+func parseAspectRatio(s string) (w, h int32, err error) {
+	if _, err := fmt.Sscanf(s, "%d:%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid aspect ratio %q, must be like \"16:9\"", s)
+	}
+	return w, h, nil
+}
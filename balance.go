@@ -0,0 +1,130 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// neighbor is a window sharing a boundary with another window.
+type neighbor struct {
+	hwnd     w32.HWND
+	frame    w32.RECT
+	vertical bool // shared boundary is a vertical line (windows are side by side)
+	distance int32
+}
+
+// balance resizes the window and its nearest neighbor sharing an edge with it
+// so that they split their combined span 50/50 along that edge.
+func balance(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	n, ok := findNeighbor(hwnd, frame)
+	if !ok {
+		fmt.Printf("balance: no window shares an edge with %q\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	fmt.Printf("> balance: neighbor 0x%x %q (vertical=%v, distance=%d)\n", n.hwnd, w32.GetWindowText(n.hwnd), n.vertical, n.distance)
+
+	_, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	a, b := splitEvenly(withoutGap(frame, area, config.Gap), withoutGap(n.frame, area, config.Gap), n.vertical)
+	okA, err := resize(hwnd, func(_, _ w32.RECT) w32.RECT { return a })
+	if err != nil {
+		return false, err
+	}
+	okB, err := resize(n.hwnd, func(_, _ w32.RECT) w32.RECT { return b })
+	if err != nil {
+		return false, err
+	}
+	return okA || okB, nil
+}
+
+// findNeighbor finds the zonable window with the closest edge to the given
+// frame, among the windows that share a vertical or horizontal boundary with it.
+func findNeighbor(hwnd w32.HWND, frame w32.RECT) (neighbor, bool) {
+	var best neighbor
+	var found bool
+	for _, w := range zonableWindows(orderZ, 0) {
+		if w.hwnd == hwnd {
+			continue
+		}
+		if n, ok := sharedBoundary(frame, w.frame); ok && (!found || n.distance < best.distance) {
+			n.hwnd = w.hwnd
+			best, found = n, true
+		}
+	}
+	return best, found
+}
+
+// sharedBoundary reports whether b has an edge within the zone tolerance (and
+// the gap between them) of an edge of a, while overlapping it along that edge.
+func sharedBoundary(a, b w32.RECT) (neighbor, bool) {
+	var out neighbor
+	var found bool
+	consider := func(d int32, vertical bool) {
+		if d < 0 {
+			d = -d
+		}
+		if d <= config.ZoneTolerance+config.Gap && (!found || d < out.distance) {
+			out = neighbor{frame: b, vertical: vertical, distance: d}
+			found = true
+		}
+	}
+	if overlap(a.Top, a.Bottom, b.Top, b.Bottom) {
+		consider(a.Right-b.Left, true)
+		consider(b.Right-a.Left, true)
+	}
+	if overlap(a.Left, a.Right, b.Left, b.Right) {
+		consider(a.Bottom-b.Top, false)
+		consider(b.Bottom-a.Top, false)
+	}
+	return out, found
+}
+
+func overlap(aStart, aEnd, bStart, bEnd int32) bool {
+	return aStart < bEnd && bStart < aEnd
+}
+
+// splitEvenly splits the combined span of a and b along their shared boundary
+// at its midpoint, and returns the new frames of a and b respectively.
+func splitEvenly(a, b w32.RECT, vertical bool) (w32.RECT, w32.RECT) {
+	if vertical {
+		first, second := &a, &b
+		if b.Left < a.Left {
+			first, second = &b, &a
+		}
+		mid := first.Left + (second.Right-first.Left)/2
+		first.Right, second.Left = mid, mid
+	} else {
+		first, second := &a, &b
+		if b.Top < a.Top {
+			first, second = &b, &a
+		}
+		mid := first.Top + (second.Bottom-first.Top)/2
+		first.Bottom, second.Top = mid, mid
+	}
+	return a, b
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// borderlessStyles are the window styles removed to make a window borderless.
+const borderlessStyles = w32.WS_CAPTION | w32.WS_THICKFRAME
+
+// framedWindow is the state of a window before it was made borderless.
+type framedWindow struct {
+	style     int32
+	rect      w32.RECT
+	maximized bool
+}
+
+var borderlessWindows = make(map[w32.HWND]framedWindow)
+
+// toggleBorderless removes the title bar and borders of the window and makes
+// it fill its monitor, or restores the original style and position if the
+// window was made borderless before.
+func toggleBorderless(hwnd w32.HWND) (bool, error) {
+	for h := range borderlessWindows {
+		if !w32.IsWindow(h) {
+			delete(borderlessWindows, h)
+		}
+	}
+	if orig, ok := borderlessWindows[hwnd]; ok {
+		delete(borderlessWindows, hwnd)
+		fmt.Printf("> borderless: restoring style=0x%x rect=%#v\n", orig.style, orig.rect)
+		w32.SetWindowLong(hwnd, GWL_STYLE, orig.style)
+		if !w32.SetWindowPos(hwnd, 0, int(orig.rect.Left), int(orig.rect.Top), int(orig.rect.Width()), int(orig.rect.Height()),
+			w32.SWP_NOZORDER|w32.SWP_NOACTIVATE|w32.SWP_FRAMECHANGED) {
+			return false, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+		}
+		if orig.maximized && !w32.ShowWindow(hwnd, w32.SW_MAXIMIZE) {
+			return false, fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
+		}
+		return true, nil
+	}
+
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	style := w32.GetWindowLong(hwnd, GWL_STYLE)
+	if style&borderlessStyles == 0 {
+		fmt.Printf("borderless: window has no frame to remove: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	maximized := w32ex.IsZoomed(hwnd)
+	if maximized && !w32.ShowWindow(hwnd, w32.SW_RESTORE) {
+		return false, fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
+	}
+	rect := w32.GetWindowRect(hwnd)
+	if rect == nil {
+		return false, fmt.Errorf("failed to GetWindowRect:%d", w32.GetLastError())
+	}
+	var monInfo w32.MONITORINFO
+	if !w32.GetMonitorInfo(w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST), &monInfo) {
+		return false, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
+	}
+	borderlessWindows[hwnd] = framedWindow{style: style, rect: *rect, maximized: maximized}
+
+	newPos := monInfo.RcMonitor
+	fmt.Printf("> borderless: removing style 0x%x (was 0x%x), resizing to: %#v\n", style&borderlessStyles, style, newPos)
+	w32.SetWindowLong(hwnd, GWL_STYLE, style&^borderlessStyles)
+	if !w32.SetWindowPos(hwnd, 0, int(newPos.Left), int(newPos.Top), int(newPos.Width()), int(newPos.Height()),
+		w32.SWP_NOZORDER|w32.SWP_NOACTIVATE|w32.SWP_FRAMECHANGED) {
+		return false, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+	}
+	markResized(hwnd)
+	return true, nil
+}
@@ -0,0 +1,643 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config is the user configuration read from config.json.
+type Config struct {
+	// ExcludedMonitors lists device names of monitors (e.g. `\\.\DISPLAY2`)
+	// that windows are never moved to when cycling between monitors.
+	ExcludedMonitors []string `json:"excludedMonitors"`
+
+	// ExcludedApps lists apps whose windows RectangleWin leaves alone.
+	ExcludedApps []AppMatcher `json:"excludedApps"`
+
+	// SkipOrientation skips monitors when cycling windows between monitors by
+	// their orientation: "portrait" skips portrait monitors, "mismatched"
+	// skips monitors whose orientation isn't the one of the window, and ""
+	// skips none.
+	SkipOrientation string `json:"skipOrientation"`
+
+	// SkipNormalize lists apps that are not restored with
+	// ShowWindow(SW_SHOWNORMAL) before being resized, for apps that
+	// misbehave when their show state changes.
+	SkipNormalize []AppMatcher `json:"skipNormalize"`
+
+	// Settle corrects once the windows of apps that move or resize themselves
+	// right after being resized.
+	Settle SettleConfig `json:"settle"`
+
+	// BorderlessToggle enables the hotkey that toggles the title bar and
+	// borders of a window. Stripping styles confuses some apps, so it is off
+	// by default.
+	BorderlessToggle bool `json:"borderlessToggle"`
+
+	// HeroZone is the zone the foreground window is snapped to by the hero
+	// stack layout, and StackArrangement is how the rest of the windows are
+	// tiled in the remaining space ("rows" or "columns").
+	HeroZone         string `json:"heroZone"`
+	StackArrangement string `json:"stackArrangement"`
+
+	// GatherZone is the zone the gather hotkey stacks the windows on the
+	// monitor in. GatherSkipForeground leaves the foreground window where it
+	// is.
+	GatherZone           string `json:"gatherZone"`
+	GatherSkipForeground bool   `json:"gatherSkipForeground"`
+
+	// TileOversizedWindows is what happens to tiled windows whose minimum size
+	// is larger than their cell: "overlap" leaves them overlapping their
+	// neighbors, "float" centers them on top of the other windows.
+	TileOversizedWindows string `json:"tileOversizedWindows"`
+
+	// WinKeyHook handles the hotkeys using the Win key with a low-level
+	// keyboard hook instead of RegisterHotKey, so that the Start menu doesn't
+	// react to them.
+	WinKeyHook bool `json:"winKeyHook"`
+
+	// MaximizeMode is how windows are maximized: "native" uses SW_MAXIMIZE,
+	// "fill" resizes the window to fill the work area while keeping it in
+	// normal state.
+	MaximizeMode string `json:"maximizeMode"`
+
+	// FollowApp is the app whose monitor windows are moved to with the
+	// follow app hotkey, which is only enabled if it is set.
+	FollowApp AppMatcher `json:"followApp"`
+
+	// SetWindowPosRetries is how many times moving a window is retried when
+	// the app transiently rejects it.
+	SetWindowPosRetries int `json:"setWindowPosRetries"`
+
+	// MoveOwnedWindows lists apps whose owned windows (e.g. detached tool
+	// windows) are moved along with the window when it is snapped, and
+	// ClampOwnedWindows keeps them inside the new position of the window.
+	MoveOwnedWindows  []AppMatcher `json:"moveOwnedWindows"`
+	ClampOwnedWindows bool         `json:"clampOwnedWindows"`
+
+	// Profile is the preset of hotkeys to use instead of the defaults (see
+	// hotKeyProfiles). HotKeys rebinds the hotkeys of actions by name (e.g.
+	// "maximize") over it.
+	Profile string                   `json:"profile"`
+	HotKeys map[string]HotKeyBinding `json:"hotkeys"`
+
+	// Cooldowns are the minimum milliseconds between two runs of an action, by
+	// name, so that holding its hotkey down doesn't repeat it too fast.
+	Cooldowns map[string]int `json:"cooldowns"`
+
+	// RemapModifier replaces a modifier in the default hotkeys with others,
+	// e.g. to use Ctrl + Alt instead of the Win key. Hotkeys rebound with
+	// HotKeys are used as configured.
+	RemapModifier ModifierRemap `json:"remapModifier"`
+
+	// MaxCycleSteps sends a cycle back to its first zone once it has moved a
+	// window that many times in a row, if not 0, to shorten long cycles.
+	MaxCycleSteps int `json:"maxCycleSteps"`
+
+	// ResumeCycles makes cycling a window that RectangleWin didn't just resize
+	// continue from the zone the window is in, instead of starting over.
+	ResumeCycles bool `json:"resumeCycles"`
+
+	// WarpCursor moves the mouse cursor to the center of windows moved to
+	// another monitor.
+	WarpCursor bool `json:"warpCursor"`
+
+	// AnchorCursor keeps the mouse cursor at the same relative position within
+	// a window it is over as the window is resized, moving it with the window.
+	AnchorCursor bool `json:"anchorCursor"`
+
+	// KeepOnScreen slides windows also to monitors that aren't next to theirs
+	// in a row of the same height (e.g. across a gap, or to a shorter
+	// monitor), clamped to stay fully within the work area of the monitor,
+	// instead of moving them to its center.
+	KeepOnScreen bool `json:"keepOnScreen"`
+
+	// ResizeModeStep is how many pixels the arrow keys move the edges of the
+	// window by in resize mode.
+	ResizeModeStep int32 `json:"resizeModeStep"`
+
+	// SpanMonitors is which monitors spanMonitors spans the window across
+	// along with its own: the one to its spanRight or spanLeft, or spanAll.
+	SpanMonitors string `json:"spanMonitors"`
+
+	// TileMaximizedWindows includes maximized windows in bulk arrangements,
+	// which leave them maximized by default.
+	TileMaximizedWindows bool `json:"tileMaximizedWindows"`
+
+	// ForegroundFallback is how hard RectangleWin tries to focus windows when
+	// Windows refuses it: foregroundNone, foregroundAttachInput, or
+	// foregroundFull, which also synthesizes an Alt key press.
+	ForegroundFallback string `json:"foregroundFallback"`
+
+	// ConfirmQuit asks for confirmation before quitting from the tray menu.
+	ConfirmQuit bool `json:"confirmQuit"`
+
+	// PresentationHideNotifications hides notifications while presentation
+	// mode is on.
+	PresentationHideNotifications bool `json:"presentationHideNotifications"`
+
+	// ZoneTolerance is how many pixels a window edge can be off from a zone or
+	// from the edge of another window while still being considered aligned
+	// with it.
+	ZoneTolerance int32 `json:"zoneTolerance"`
+
+	// Gap is the space in pixels left between snapped windows, and between
+	// them and the edges of the work area. Gaps are the gaps that cycleGap
+	// cycles through.
+	Gap  int32   `json:"gap"`
+	Gaps []int32 `json:"gaps"`
+
+	// FlushTaskbarEdge puts the visible edge of windows snapped against the
+	// taskbar exactly on the work area edge next to it, without a gap.
+	FlushTaskbarEdge bool `json:"flushTaskbarEdge"`
+
+	// CornerSize is the width and height of the corner zones, in percent of
+	// the work area.
+	CornerSize int32 `json:"cornerSize"`
+
+	// ExcludeTaskbars leaves the taskbars docked to the edges of monitors out
+	// of their work areas, as the work areas the system reports don't always do
+	// for the taskbars of secondary monitors.
+	ExcludeTaskbars bool `json:"excludeTaskbars"`
+
+	// RememberMaximized saves the placement of maximized windows when they are
+	// snapped, and enables the hotkey maximizing them back.
+	RememberMaximized bool `json:"rememberMaximized"`
+
+	// SplitRatio is the percentage of the work area the two-thirds zones take
+	// (the one-thirds zones take the rest), or 0 for two thirds.
+	// AppSplitRatios overrides it for the windows of apps.
+	SplitRatio     int32           `json:"splitRatio"`
+	AppSplitRatios []AppSplitRatio `json:"appSplitRatios"`
+
+	// DpiVirtualizationCompensation stops RectangleWin from scaling the frame
+	// of windows of DPI unaware apps, which Windows already scales.
+	DpiVirtualizationCompensation bool `json:"dpiVirtualizationCompensation"`
+
+	// NewWindowsUnderCursor moves new windows opened on another monitor than
+	// the one under the cursor to the center of that monitor.
+	NewWindowsUnderCursor bool `json:"newWindowsUnderCursor"`
+
+	// DesktopUnderCursor makes the show desktop hotkey act on the monitor under
+	// the cursor, instead of the one of the foreground window.
+	DesktopUnderCursor bool `json:"desktopUnderCursor"`
+
+	// ReverseModifier is the modifier ("shift", "ctrl" or "alt") added to the
+	// hotkeys cycling between zones to cycle in reverse.
+	ReverseModifier string `json:"reverseModifier"`
+
+	// MonitorDefaultZones maps monitor device names to the zone that windows
+	// RectangleWin has never placed are snapped to when focused on them.
+	MonitorDefaultZones map[string]string `json:"monitorDefaultZones"`
+
+	// ReflowOnWorkAreaChange fits the windows RectangleWin placed to the new
+	// work area of their monitor when it changes (e.g. when the taskbar is
+	// resized or set to auto-hide).
+	ReflowOnWorkAreaChange bool `json:"reflowOnWorkAreaChange"`
+
+	// MouseBindings binds actions by name to mouse buttons.
+	MouseBindings map[string]MouseBinding `json:"mouseBindings"`
+
+	// QuietHours is a daily time range notifications are hidden in.
+	QuietHours QuietHoursConfig `json:"quietHours"`
+
+	// RestoreFocus gives the focus back to the foreground window after bulk
+	// operations, if moving the other windows took it away.
+	RestoreFocus bool `json:"restoreFocus"`
+
+	// MaxBulkWindows is how many windows operations arranging many windows at
+	// once touch at most, or 0 for no limit.
+	MaxBulkWindows int `json:"maxBulkWindows"`
+
+	// TilingPresets are named master and stack arrangements of the windows on
+	// a monitor, each applied with its own hotkey, and rotated with it and
+	// the reverse modifier.
+	TilingPresets []TilingPreset `json:"tilingPresets"`
+
+	// Layouts are the role layouts applyLayout arranges windows in, and Layout
+	// is the name of the one used until another is chosen in the tray menu
+	// (the first one if empty).
+	Layouts []RoleLayout `json:"layouts"`
+	Layout  string       `json:"layout"`
+
+	// AppZones are the positions the windows of apps are put in by the apply
+	// app zones action, as written by the dump layout action.
+	AppZones []AppZone `json:"appZones"`
+
+	// DumpLayoutToClipboard also copies dumped layouts to the clipboard.
+	DumpLayoutToClipboard bool `json:"dumpLayoutToClipboard"`
+
+	// MonitorZoneSets replaces the zones of the cycles (see cycleNames) on
+	// monitors by device name, e.g. to cycle a portrait monitor through
+	// vertical thirds.
+	MonitorZoneSets map[string]map[string][]string `json:"monitorZoneSets"`
+
+	// Resnap are the zones resnap snaps windows back to.
+	Resnap ResnapConfig `json:"resnap"`
+
+	// OnStartup runs actions once RectangleWin has started.
+	OnStartup OnStartupConfig `json:"onStartup"`
+
+	// SplitRounding is which zone gets the leftover pixel when the work area
+	// doesn't split evenly: "end" gives it to the right/bottom zone, "start" to
+	// the left/top zone, "nearest" rounds the split to the nearest pixel, and
+	// "awayFromCenter" gives it to the zone on the edge of the work area, so
+	// that e.g. the left and right thirds are the same size.
+	SplitRounding string `json:"splitRounding"`
+
+	// AspectRatio is the aspect ratio ("W:H") the fit aspect ratio hotkey sizes
+	// windows to. AppAspectRatios overrides it for the windows of apps.
+	AspectRatio     string           `json:"aspectRatio"`
+	AppAspectRatios []AppAspectRatio `json:"appAspectRatios"`
+
+	// TrayClicks runs actions by name on clicks on the tray icon ("middle" or
+	// "double"), instead of opening the menu.
+	TrayClicks map[string]string `json:"trayClicks"`
+
+	// FlashZone briefly outlines where windows are moved to.
+	FlashZone FlashZoneConfig `json:"flashZone"`
+
+	// FocusMode dims the monitors other than the one with the foreground
+	// window, when toggled.
+	FocusMode FocusModeConfig `json:"focusMode"`
+
+	// Feedback is given after each action, unless overridden for the action by
+	// its name in ActionFeedback.
+	Feedback       FeedbackConfig            `json:"feedback"`
+	ActionFeedback map[string]ActionFeedback `json:"actionFeedback"`
+}
+
+// AppSplitRatio is the split ratio used for the windows of an app.
+type AppSplitRatio struct {
+	App   AppMatcher `json:"app"`
+	Ratio int32      `json:"ratio"`
+}
+
+// OnStartupConfig lists actions by name (see HotKey.name) to run on startup,
+// after waiting for DelayMs.
+type OnStartupConfig struct {
+	Actions []string `json:"actions"`
+	DelayMs int      `json:"delayMs"`
+}
+
+// QuietHoursConfig is a time range, such as 22:00-07:00. It is
+// unset if Start is empty.
+type QuietHoursConfig struct {
+	Start string `json:"start"` // "HH:MM"
+	End   string `json:"end"`   // "HH:MM"
+}
+
+// FlashZoneConfig configures the outline shown where windows are moved to.
+type FlashZoneConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Color      string `json:"color"` // "#RRGGBB"
+	Thickness  int32  `json:"thickness"`
+	DurationMs int    `json:"durationMs"`
+}
+
+const (
+	stackRows    = "rows"
+	stackColumns = "columns"
+
+	oversizedOverlap = "overlap"
+	oversizedFloat   = "float"
+
+	maximizeNative = "native"
+	maximizeFill   = "fill"
+
+	skipPortrait   = "portrait"
+	skipMismatched = "mismatched"
+
+	roundEnd     = "end"
+	roundStart   = "start"
+	roundNearest = "nearest"
+	roundOut     = "awayFromCenter"
+
+	// the retries sleep on the main thread, which runs the low-level keyboard
+	// and mouse hooks, so they must add up to well under LowLevelHooksTimeout
+	// (300ms by default) for Windows not to remove the hooks: 20+40+80ms
+	maxSetWindowPosRetries = 3
+	setWindowPosBackoff    = 20 * time.Millisecond // doubled on each retry
+)
+
+var maximizeModeDescriptions = map[string]string{
+	maximizeNative: "native (SW_MAXIMIZE: the window is maximized, hides an auto-hiding taskbar and restores to its previous size)",
+	maximizeFill:   "fill (the window stays in normal state and fills the work area: no restore size, Snap Assist and the taskbar behave as with any other window)",
+}
+
+var config = defaultConfig()
+
+func defaultConfig() Config {
+	return Config{
+		HeroZone:         "leftHalf",
+		StackArrangement: stackRows,
+		GatherZone:       "rightOneThirds",
+
+		TileOversizedWindows: oversizedOverlap,
+		MaximizeMode:         maximizeNative,
+		SetWindowPosRetries:  3,
+
+		PresentationHideNotifications: true,
+		ZoneTolerance:                 16,
+		CornerSize:                    50,
+		ReverseModifier:               "shift",
+		MaxBulkWindows:                20,
+		RestoreFocus:                  true,
+		ForegroundFallback:            foregroundAttachInput,
+		AspectRatio:                   "16:9",
+		SplitRounding:                 roundEnd,
+		Gaps:                          []int32{0, 8, 16, 24},
+
+		DpiVirtualizationCompensation: true,
+		ExcludeTaskbars:               true,
+		KeepOnScreen:                  true,
+		SpanMonitors:                  spanRight,
+		ResizeModeStep:                10,
+
+		Cooldowns: map[string]int{
+			// moving a window across monitors is the most disruptive to repeat
+			"nextMonitor":          500,
+			"nextMonitorMaximized": 500,
+			"slideLeft":            500,
+			"slideRight":           500,
+		},
+
+		OnStartup: OnStartupConfig{DelayMs: 5000},
+		Resnap:    ResnapConfig{Tolerance: 100},
+		FocusMode: FocusModeConfig{Opacity: 60, FollowFocus: true},
+		Settle:    SettleConfig{DelayMs: 100, Tolerance: 2},
+		FlashZone: FlashZoneConfig{Color: "#0078D7", Thickness: 4, DurationMs: 150},
+	}
+}
+
+func (c Config) validate() error {
+	if _, ok := zones[c.HeroZone]; !ok {
+		return fmt.Errorf("heroZone: unknown zone %q", c.HeroZone)
+	}
+	if _, ok := zones[c.GatherZone]; !ok {
+		return fmt.Errorf("gatherZone: unknown zone %q", c.GatherZone)
+	}
+	if c.StackArrangement != stackRows && c.StackArrangement != stackColumns {
+		return fmt.Errorf("stackArrangement: must be %q or %q, got %q", stackRows, stackColumns, c.StackArrangement)
+	}
+	if c.TileOversizedWindows != oversizedOverlap && c.TileOversizedWindows != oversizedFloat {
+		return fmt.Errorf("tileOversizedWindows: must be %q or %q, got %q", oversizedOverlap, oversizedFloat, c.TileOversizedWindows)
+	}
+	if c.ForegroundFallback != foregroundNone && c.ForegroundFallback != foregroundAttachInput && c.ForegroundFallback != foregroundFull {
+		return fmt.Errorf("foregroundFallback: must be %q, %q or %q, got %q", foregroundNone, foregroundAttachInput, foregroundFull, c.ForegroundFallback)
+	}
+	if c.ResizeModeStep < 1 {
+		return fmt.Errorf("resizeModeStep: must be at least 1, got %d", c.ResizeModeStep)
+	}
+	if c.SpanMonitors != spanRight && c.SpanMonitors != spanLeft && c.SpanMonitors != spanAll {
+		return fmt.Errorf("spanMonitors: must be %q, %q or %q, got %q", spanRight, spanLeft, spanAll, c.SpanMonitors)
+	}
+	if c.SkipOrientation != "" && c.SkipOrientation != skipPortrait && c.SkipOrientation != skipMismatched {
+		return fmt.Errorf("skipOrientation: must be %q or %q, got %q", skipPortrait, skipMismatched, c.SkipOrientation)
+	}
+	if _, ok := maximizeModeDescriptions[c.MaximizeMode]; !ok {
+		return fmt.Errorf("maximizeMode: must be %q or %q, got %q", maximizeNative, maximizeFill, c.MaximizeMode)
+	}
+	if c.SetWindowPosRetries < 0 || c.SetWindowPosRetries > maxSetWindowPosRetries {
+		return fmt.Errorf("setWindowPosRetries: must be between 0 and %d, got %d", maxSetWindowPosRetries, c.SetWindowPosRetries)
+	}
+	if mod, err := parseModifiers([]string{c.ReverseModifier}); err != nil || mod == MOD_WIN {
+		return fmt.Errorf("reverseModifier: must be \"shift\", \"ctrl\" or \"alt\", got %q", c.ReverseModifier)
+	}
+	for device, zone := range c.MonitorDefaultZones {
+		if _, ok := zones[zone]; !ok {
+			return fmt.Errorf("monitorDefaultZones: %s: unknown zone %q", device, zone)
+		}
+	}
+	if c.ZoneTolerance < 0 {
+		return fmt.Errorf("zoneTolerance: must not be negative, got %d", c.ZoneTolerance)
+	}
+	if c.Gap < 0 {
+		return fmt.Errorf("gap: must not be negative, got %d", c.Gap)
+	}
+	for _, g := range c.Gaps {
+		if g < 0 {
+			return fmt.Errorf("gaps: must not be negative, got %d", g)
+		}
+	}
+	if c.CornerSize < 1 || c.CornerSize > 100 {
+		return fmt.Errorf("cornerSize: must be a percentage between 1 and 100, got %d", c.CornerSize)
+	}
+	if _, err := parseColor(c.FlashZone.Color); err != nil {
+		return fmt.Errorf("flashZone: %w", err)
+	}
+	if c.FlashZone.Thickness < 1 || c.FlashZone.DurationMs < 1 {
+		return fmt.Errorf("flashZone: thickness and durationMs must be positive")
+	}
+	if c.SplitRatio < 0 || c.SplitRatio > 99 {
+		return fmt.Errorf("splitRatio: must be a percentage between 1 and 99, or 0, got %d", c.SplitRatio)
+	}
+	for _, r := range c.AppSplitRatios {
+		if r.Ratio < 1 || r.Ratio > 99 {
+			return fmt.Errorf("appSplitRatios: %s: ratio must be a percentage between 1 and 99, got %d", r.App, r.Ratio)
+		}
+	}
+	if c.SplitRounding != roundEnd && c.SplitRounding != roundStart && c.SplitRounding != roundNearest && c.SplitRounding != roundOut {
+		return fmt.Errorf("splitRounding: must be %q, %q, %q or %q, got %q", roundEnd, roundStart, roundNearest, roundOut, c.SplitRounding)
+	}
+	if _, _, err := parseAspectRatio(c.AspectRatio); err != nil {
+		return fmt.Errorf("aspectRatio: %w", err)
+	}
+	for _, r := range c.AppAspectRatios {
+		if _, _, err := parseAspectRatio(r.Ratio); err != nil {
+			return fmt.Errorf("appAspectRatios: %s: %w", r.App, err)
+		}
+	}
+	if c.MaxCycleSteps < 0 {
+		return fmt.Errorf("maxCycleSteps: must not be negative, got %d", c.MaxCycleSteps)
+	}
+	if c.MaxBulkWindows < 0 {
+		return fmt.Errorf("maxBulkWindows: must not be negative, got %d", c.MaxBulkWindows)
+	}
+	if c.Settle.DelayMs < 0 || c.Settle.Tolerance < 0 {
+		return fmt.Errorf("settle: delayMs and tolerance must not be negative, got %d and %d", c.Settle.DelayMs, c.Settle.Tolerance)
+	}
+	if c.FocusMode.Opacity < 0 || c.FocusMode.Opacity > 100 {
+		return fmt.Errorf("focusMode: opacity must be between 0 and 100, got %d", c.FocusMode.Opacity)
+	}
+	if len(c.TilingPresets) > maxTilingPresets {
+		return fmt.Errorf("tilingPresets: at most %d presets are supported, got %d", maxTilingPresets, len(c.TilingPresets))
+	}
+	presets := make(map[string]bool)
+	for _, p := range c.TilingPresets {
+		if p.Name == "" || presets[p.Name] {
+			return fmt.Errorf("tilingPresets: presets must have unique names, got %q", p.Name)
+		}
+		presets[p.Name] = true
+		if _, ok := zones[p.Master]; !ok {
+			return fmt.Errorf("tilingPresets: %s: unknown master zone %q", p.Name, p.Master)
+		}
+		if p.Masters < 0 {
+			return fmt.Errorf("tilingPresets: %s: masters must not be negative, got %d", p.Name, p.Masters)
+		}
+		if p.Stack != "" && p.Stack != stackRows && p.Stack != stackColumns {
+			return fmt.Errorf("tilingPresets: %s: stack must be %q or %q, got %q", p.Name, stackRows, stackColumns, p.Stack)
+		}
+		if _, _, err := p.HotKey.parse(); err != nil {
+			return fmt.Errorf("tilingPresets: %s: hotkey: %w", p.Name, err)
+		}
+	}
+	layouts := make(map[string]bool)
+	for _, l := range c.Layouts {
+		if l.Name == "" || layouts[l.Name] {
+			return fmt.Errorf("layouts: layouts must have unique names, got %q", l.Name)
+		}
+		layouts[l.Name] = true
+		if len(l.Roles) == 0 {
+			return fmt.Errorf("layouts: %s: has no roles", l.Name)
+		}
+		for i, r := range l.Roles {
+			if r.Name == "" {
+				return fmt.Errorf("layouts: %s: roles must have names", l.Name)
+			}
+			if _, ok := zones[r.Zone]; !ok {
+				return fmt.Errorf("layouts: %s: %s: unknown zone %q", l.Name, r.Name, r.Zone)
+			}
+			if i > 0 && r.App.Class == "" && r.App.Exe == "" && r.App.Title == "" {
+				return fmt.Errorf("layouts: %s: %s: app must be set", l.Name, r.Name)
+			}
+		}
+	}
+	if c.Layout != "" && !layouts[c.Layout] {
+		return fmt.Errorf("layout: unknown layout %q", c.Layout)
+	}
+	for device, sets := range c.MonitorZoneSets {
+		for name, set := range sets {
+			if !containsString(cycleNames, name) {
+				return fmt.Errorf("monitorZoneSets: %s: unknown cycle %q, valid cycles are: %s", device, name, strings.Join(cycleNames, " "))
+			}
+			if len(set) == 0 {
+				return fmt.Errorf("monitorZoneSets: %s: %s has no zones", device, name)
+			}
+			for _, z := range set {
+				if _, ok := zones[z]; !ok {
+					return fmt.Errorf("monitorZoneSets: %s: %s: unknown zone %q", device, name, z)
+				}
+			}
+		}
+	}
+	for _, z := range c.Resnap.Zones {
+		if _, ok := zones[z]; !ok {
+			return fmt.Errorf("resnap: unknown zone %q", z)
+		}
+	}
+	if c.Resnap.Tolerance < 0 {
+		return fmt.Errorf("resnap: tolerance must not be negative, got %d", c.Resnap.Tolerance)
+	}
+	if c.OnStartup.DelayMs < 0 {
+		return fmt.Errorf("onStartup: delayMs must not be negative, got %d", c.OnStartup.DelayMs)
+	}
+	if c.QuietHours.Start != "" {
+		if _, err := parseClock(c.QuietHours.Start); err != nil {
+			return fmt.Errorf("quietHours: start: %w", err)
+		}
+		if _, err := parseClock(c.QuietHours.End); err != nil {
+			return fmt.Errorf("quietHours: end: %w", err)
+		}
+	}
+	if _, ok := hotKeyProfiles[c.Profile]; !ok && c.Profile != "" {
+		return fmt.Errorf("profile: unknown profile %q, valid profiles are: %s", c.Profile, strings.Join(profileNames(), " "))
+	}
+	for name, b := range c.HotKeys {
+		if _, _, err := b.parse(); err != nil {
+			return fmt.Errorf("hotkeys: %s: %w", name, err)
+		}
+	}
+	if c.RemapModifier.From != "" {
+		if _, to, err := c.RemapModifier.parse(); err != nil {
+			return fmt.Errorf("remapModifier: %w", err)
+		} else if to == 0 {
+			return errors.New("remapModifier: to: must have at least one modifier")
+		}
+	}
+	for name, ms := range c.Cooldowns {
+		if ms < 0 {
+			return fmt.Errorf("cooldowns: %s: must not be negative, got %d", name, ms)
+		}
+	}
+	for click := range c.TrayClicks {
+		if _, ok := trayClicksByName[click]; !ok {
+			return fmt.Errorf("trayClicks: unknown click %q, must be \"middle\" or \"double\"", click)
+		}
+	}
+	for name, b := range c.MouseBindings {
+		if _, _, err := b.parse(); err != nil {
+			return fmt.Errorf("mouseBindings: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// configDir returns the directory RectangleWin keeps its files in
+// (%APPDATA%\RectangleWin).
+func configDir() (string, error) {
+	d, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "RectangleWin"), nil
+}
+
+func configPath() (string, error) {
+	d, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "config.json"), nil
+}
+
+// loadConfig reads the config file. If it doesn't exist, the default
+// configuration is returned.
+func loadConfig() (Config, error) {
+	c := defaultConfig()
+	p, err := configPath()
+	if err != nil {
+		return c, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return defaultConfig(), fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	if err := c.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("invalid config %s: %w", p, err)
+	}
+	return c, nil
+}
+
+func (c Config) isExcludedMonitor(device string) bool {
+	for _, v := range c.ExcludedMonitors {
+		if strings.EqualFold(v, device) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// cursorAnchor is the position of the mouse cursor relative to the visible
+// frame of a window, from 0 (left/top edge) to 1 (right/bottom edge).
+type cursorAnchor struct {
+	x, y float64
+}
+
+// anchorCursor returns the position of the cursor within the frame, if
+// config.AnchorCursor is set and the cursor is within the frame.
+func anchorCursor(frame w32.RECT) (cursorAnchor, bool) {
+	if !config.AnchorCursor || frame.Width() <= 0 || frame.Height() <= 0 {
+		return cursorAnchor{}, false
+	}
+	x, y, ok := w32.GetCursorPos()
+	if !ok {
+		fmt.Printf("warn: failed to GetCursorPos:%d\n", w32.GetLastError())
+		return cursorAnchor{}, false
+	}
+	if !inRect(x, y, frame) {
+		return cursorAnchor{}, false
+	}
+	return cursorAnchor{
+		x: float64(int32(x)-frame.Left) / float64(frame.Width()),
+		y: float64(int32(y)-frame.Top) / float64(frame.Height()),
+	}, true
+}
+
+// warp moves the cursor to the same relative position within the visible
+// frame the window has now.
+func (a cursorAnchor) warp(hwnd w32.HWND) {
+	ok, frame := w32.DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS(hwnd)
+	if !ok {
+		fmt.Printf("warn: failed to DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS:%d\n", w32.GetLastError())
+		return
+	}
+	x := frame.Left + int32(a.x*float64(frame.Width()))
+	y := frame.Top + int32(a.y*float64(frame.Height()))
+	x = max32(frame.Left, min32(x, frame.Right-1))
+	y = max32(frame.Top, min32(y, frame.Bottom-1))
+	fmt.Printf("> anchoring cursor at (%.2f,%.2f) of the window: (%d,%d)\n", a.x, a.y, x, y)
+	if !w32.SetCursorPos(int(x), int(y)) {
+		fmt.Printf("warn: failed to SetCursorPos:%d\n", w32.GetLastError())
+	}
+}
+
+func inRect(x, y int, r w32.RECT) bool {
+	return int32(x) >= r.Left && int32(x) < r.Right && int32(y) >= r.Top && int32(y) < r.Bottom
+}
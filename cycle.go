@@ -0,0 +1,128 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// cycleState is where each zone cycle is at for the window cycled last. The
+// turn of a cycle is the index of its next zone, one past the current one.
+// Cycling one of them starts the others over.
+type cycleState struct {
+	names []string
+	turns []int
+	steps int      // moves in the current cycle since it started or wrapped
+	hwnd  w32.HWND // window the turns are for
+
+	// zoneOf returns the index of the zone of cycle i the window is in, to
+	// resume cycles from.
+	zoneOf func(hwnd w32.HWND, i int) (int, bool)
+}
+
+func newCycleState(names []string, zoneOf func(hwnd w32.HWND, i int) (int, bool)) *cycleState {
+	return &cycleState{names: names, turns: make([]int, len(names)), zoneOf: zoneOf}
+}
+
+// advance returns the index of the zone to move the window to in cycle i, of
+// n zones: the next one, or the previous one if reverse. Cycling a window other
+// than the one the turns are for starts the cycles over, from the zone the
+// window is in if config.ResumeCycles, and a cycle goes back to its first zone
+// after config.MaxCycleSteps moves. Call moved once the window is there.
+func (c *cycleState) advance(hwnd w32.HWND, i, n int, reverse bool) int {
+	if c.hwnd != hwnd {
+		c.reset()
+		if config.ResumeCycles {
+			if j, ok := c.zoneOf(hwnd, i); ok {
+				fmt.Printf("> window is in zone #%d of the cycle, resuming from there\n", j)
+				c.resume(hwnd, i, j)
+			}
+		}
+	}
+	j := c.next(i, n)
+	if reverse {
+		j = c.prev(i, n)
+	}
+	if c.exhausted(config.MaxCycleSteps) {
+		fmt.Printf("> cycled %d times, back to the first zone\n", config.MaxCycleSteps)
+		j = 0
+		c.wrap()
+	}
+	return j
+}
+
+// reset starts all cycles over.
+func (c *cycleState) reset() {
+	c.turns = make([]int, len(c.names))
+	c.steps = 0
+	c.hwnd = 0
+}
+
+// resume continues cycle i of the window from its zone j, as if it had been
+// cycled there.
+func (c *cycleState) resume(hwnd w32.HWND, i, j int) {
+	c.turns[i] = j + 1
+	c.hwnd = hwnd
+}
+
+// next returns the index of the next zone of cycle i, of n zones.
+func (c *cycleState) next(i, n int) int {
+	return modNeg(c.turns[i], n)
+}
+
+// prev returns the index of the previous zone of cycle i, of n zones.
+func (c *cycleState) prev(i, n int) int {
+	j := c.turns[i]
+	if j > 0 {
+		j -= 2
+	}
+	return modNeg(j, n)
+}
+
+// exhausted reports whether the current cycle has taken max steps, after which
+// it goes back to its first zone. There is no limit if max is 0.
+func (c *cycleState) exhausted(max int) bool {
+	return max > 0 && c.steps >= max
+}
+
+// wrap makes the current cycle count its steps from its first zone again.
+func (c *cycleState) wrap() {
+	c.steps = 0
+}
+
+// moved records that the window was moved to zone j of cycle i, starting the
+// other cycles over.
+func (c *cycleState) moved(hwnd w32.HWND, i, j int) {
+	if c.turns[i] == 0 {
+		c.steps = 0 // cycle i is starting
+	}
+	c.steps++
+	for k := range c.turns {
+		c.turns[k] = 0
+	}
+	c.turns[i] = j + 1
+	c.hwnd = hwnd
+}
+
+func (c *cycleState) String() string {
+	parts := make([]string, len(c.names))
+	for i, name := range c.names {
+		parts[i] = fmt.Sprintf("%s=%d", name, c.turns[i])
+	}
+	return fmt.Sprintf("cycles of window 0x%x: %s (%d steps)", c.hwnd, strings.Join(parts, " "), c.steps)
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// notInZone is a zoneOf for windows in none of the zones of the cycles.
+func notInZone(w32.HWND, int) (int, bool) { return 0, false }
+
+// cycleTo advances cycle i of n zones for the window, and moves it there.
+func cycleTo(c *cycleState, hwnd w32.HWND, i, n int, reverse bool) int {
+	j := c.advance(hwnd, i, n, reverse)
+	c.moved(hwnd, i, j)
+	return j
+}
+
+// assertCycle cycles the window through cycle i of n zones, checking the zone
+// indices it goes to.
+func assertCycle(t *testing.T, c *cycleState, hwnd w32.HWND, i, n int, reverse bool, want ...int) {
+	t.Helper()
+	for k, w := range want {
+		if got := cycleTo(c, hwnd, i, n, reverse); got != w {
+			t.Fatalf("move #%d (reverse=%v) went to zone %d, want %d (%s)", k+1, reverse, got, w, c)
+		}
+	}
+}
+
+func TestCycleNextWraps(t *testing.T) {
+	c := newCycleState([]string{"a", "b"}, notInZone)
+	assertCycle(t, c, 1, 0, 3, false, 0, 1, 2, 0, 1)
+}
+
+func TestCyclePrevWraps(t *testing.T) {
+	c := newCycleState([]string{"a", "b"}, notInZone)
+	assertCycle(t, c, 1, 0, 3, false, 0, 1)
+	assertCycle(t, c, 1, 0, 3, true, 0, 2, 1, 0)
+}
+
+func TestCyclePrevFromFreshState(t *testing.T) {
+	c := newCycleState([]string{"a", "b"}, notInZone)
+	assertCycle(t, c, 1, 1, 4, true, 0, 3, 2)
+}
+
+func TestCycleStartsOver(t *testing.T) {
+	c := newCycleState([]string{"a", "b"}, notInZone)
+	assertCycle(t, c, 1, 0, 3, false, 0, 1)
+	assertCycle(t, c, 2, 0, 3, false, 0) // another window
+	assertCycle(t, c, 2, 1, 3, false, 0) // another cycle
+	assertCycle(t, c, 2, 0, 3, false, 0, 1)
+	c.reset()
+	assertCycle(t, c, 2, 0, 3, false, 0)
+}
+
+func TestCycleResume(t *testing.T) {
+	defer func(r bool) { config.ResumeCycles = r }(config.ResumeCycles)
+	inSecondZone := func(w32.HWND, int) (int, bool) { return 1, true }
+	for _, tc := range []struct {
+		name    string
+		resume  bool
+		zoneOf  func(w32.HWND, int) (int, bool)
+		reverse bool
+		want    []int
+	}{
+		{name: "next", resume: true, zoneOf: inSecondZone, want: []int{2, 0, 1}},
+		{name: "prev", resume: true, zoneOf: inSecondZone, reverse: true, want: []int{0, 2, 1}},
+		{name: "not in a zone", resume: true, zoneOf: notInZone, want: []int{0, 1}},
+		{name: "disabled", zoneOf: inSecondZone, want: []int{0, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config.ResumeCycles = tc.resume
+			c := newCycleState([]string{"a", "b"}, tc.zoneOf)
+			assertCycle(t, c, 1, 0, 3, tc.reverse, tc.want...)
+
+			// the window cycled last doesn't resume
+			c.zoneOf = func(w32.HWND, int) (int, bool) { t.Fatal("zoneOf called for the window cycled last"); return 0, false }
+			cycleTo(c, 1, 0, 3, tc.reverse)
+		})
+	}
+}
+
+func TestCycleMaxSteps(t *testing.T) {
+	defer func(m int) { config.MaxCycleSteps = m }(config.MaxCycleSteps)
+	config.MaxCycleSteps = 2
+	c := newCycleState([]string{"a", "b"}, notInZone)
+	assertCycle(t, c, 1, 0, 4, false, 0, 1, 0, 1, 0)
+
+	config.MaxCycleSteps = 4
+	c.reset()
+	assertCycle(t, c, 1, 0, 3, false, 0, 1, 2, 0, 0, 1, 2, 0, 0)
+	assertCycle(t, c, 1, 1, 3, false, 0) // another cycle starts counting over
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+var (
+	// monitorDesktops are the windows minimized by toggleMonitorDesktop on
+	// each monitor, topmost first, to restore them on the next toggle.
+	monitorDesktops = make(map[w32.HMONITOR][]w32.HWND)
+	// lastDesktop is the monitor whose desktop was shown last.
+	lastDesktop w32.HMONITOR
+)
+
+// desktopMonitor returns the monitor toggleMonitorDesktop acts on: the one of
+// the foreground window, or the one under the mouse cursor if
+// desktopUnderCursor is set. When the foreground window isn't zonable, like
+// the desktop focused after showing it, it is the monitor whose desktop was
+// shown last.
+func desktopMonitor() (w32.HMONITOR, error) {
+	if config.DesktopUnderCursor {
+		x, y, ok := w32.GetCursorPos()
+		if !ok {
+			return 0, fmt.Errorf("failed to GetCursorPos:%d", w32.GetLastError())
+		}
+		return w32.MonitorFromPoint(x, y, w32.MONITOR_DEFAULTTONEAREST), nil
+	}
+	hwnd := w32.GetForegroundWindow()
+	if _, ok := monitorDesktops[lastDesktop]; ok && (hwnd == 0 || !isZonableWindow(hwnd)) {
+		return lastDesktop, nil
+	}
+	return w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST), nil
+}
+
+// toggleMonitorDesktop minimizes the windows on the monitor of the foreground
+// window to show its desktop, leaving the other monitors alone, and focuses
+// the desktop. Toggling it again restores the windows it minimized there.
+func toggleMonitorDesktop() (bool, error) {
+	mon, err := desktopMonitor()
+	if err != nil {
+		return false, err
+	}
+	if hidden, ok := monitorDesktops[mon]; ok {
+		delete(monitorDesktops, mon)
+		if restoreDesktop(hidden) {
+			return true, nil
+		}
+		// all restored by the user since, so show the desktop again
+	}
+
+	var hidden []w32.HWND
+	for _, w := range zonableWindows(orderZ, mon) {
+		fmt.Printf("> minimizing window 0x%x %q\n", w.hwnd, w32.GetWindowText(w.hwnd))
+		w32.ShowWindow(w.hwnd, w32.SW_MINIMIZE)
+		hidden = append(hidden, w.hwnd)
+	}
+	if len(hidden) == 0 {
+		fmt.Println("no windows to minimize on the monitor")
+		return false, nil
+	}
+	monitorDesktops[mon] = hidden
+	lastDesktop = mon
+	// like Win + D, instead of the next window, which may be on another monitor
+	if desktop := w32.FindWindow("Progman", ""); desktop != 0 {
+		setForeground(desktop)
+	}
+	return true, nil
+}
+
+// restoreDesktop restores the windows still minimized among the given ones,
+// bottommost first so that they keep their Z-order, and reports whether there
+// were any.
+func restoreDesktop(hidden []w32.HWND) bool {
+	var restored bool
+	for i := len(hidden) - 1; i >= 0; i-- {
+		hwnd := hidden[i]
+		if !w32.IsWindow(hwnd) || !w32ex.IsIconic(hwnd) {
+			continue // closed or restored without us noticing
+		}
+		fmt.Printf("> restoring window 0x%x %q\n", hwnd, w32.GetWindowText(hwnd))
+		w32.ShowWindow(hwnd, w32.SW_RESTORE)
+		restored = true
+	}
+	return restored
+}
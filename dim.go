@@ -0,0 +1,131 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+)
+
+const dimClassName = "RectangleWinDim"
+
+// FocusModeConfig configures the focus mode dimming the other monitors.
+type FocusModeConfig struct {
+	Opacity     int  `json:"opacity"`     // percent, of black
+	FollowFocus bool `json:"followFocus"` // undim the monitor windows get focused on
+}
+
+var (
+	focusMode          bool
+	dimWindows         = make(map[w32.HMONITOR]w32.HWND)
+	dimClassRegistered bool
+)
+
+// toggleFocusMode dims every monitor except the one of the foreground window
+// with a dark click-through overlay, or removes the overlays.
+func toggleFocusMode() {
+	focusMode = !focusMode
+	acted = true // toggling modes gives feedback like moving windows does
+	fmt.Printf("> focus mode: %v\n", focusMode)
+	if !focusMode {
+		for d, h := range dimWindows {
+			w32.DestroyWindow(h)
+			delete(dimWindows, d)
+		}
+		return
+	}
+	dimMonitors(w32.MonitorFromWindow(w32.GetForegroundWindow(), w32.MONITOR_DEFAULTTONEAREST))
+}
+
+// onFocusModeForeground moves the dimming away from the monitor of a window
+// getting focused, in focus mode following the focus.
+func onFocusModeForeground(hwnd w32.HWND) {
+	if !focusMode || !config.FocusMode.FollowFocus || !isZonableWindow(hwnd) {
+		return
+	}
+	dimMonitors(w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST))
+}
+
+// dimMonitors covers each monitor except active with an overlay, creating the
+// missing ones, and removes the overlays of active and disconnected monitors.
+func dimMonitors(active w32.HMONITOR) {
+	connected := make(map[w32.HMONITOR]bool)
+	EnumMonitors(func(d w32.HMONITOR) bool {
+		connected[d] = true
+		if d == active {
+			return true
+		}
+		var v w32.MONITORINFO
+		if !w32.GetMonitorInfo(d, &v) {
+			fmt.Printf("warn: focus mode: failed to GetMonitorInfo:%d\n", w32.GetLastError())
+			return true
+		}
+		h, ok := dimWindows[d]
+		if !ok {
+			var err error
+			if h, err = createDimWindow(); err != nil {
+				fmt.Printf("warn: focus mode: %v\n", err)
+				return true
+			}
+			dimWindows[d] = h
+		}
+		r := v.RcMonitor
+		if !w32.SetWindowPos(h, w32.HWND_TOPMOST, int(r.Left), int(r.Top), int(r.Width()), int(r.Height()), w32.SWP_NOACTIVATE|w32.SWP_SHOWWINDOW) {
+			fmt.Printf("warn: focus mode: failed to SetWindowPos:%d\n", w32.GetLastError())
+		}
+		return true
+	})
+	for d, h := range dimWindows {
+		if d == active || !connected[d] {
+			w32.DestroyWindow(h)
+			delete(dimWindows, d)
+		}
+	}
+}
+
+// createDimWindow creates an overlay for dimMonitors. Like the flash window,
+// it is a tool window that can't be activated, which keeps it out of
+// isZonableWindow and so of every operation going over windows.
+func createDimWindow() (w32.HWND, error) {
+	instance := w32.GetModuleHandle("")
+	class := syscall.StringToUTF16Ptr(dimClassName)
+	if !dimClassRegistered {
+		wc := w32.WNDCLASSEX{
+			WndProc:    syscall.NewCallback(w32.DefWindowProc),
+			Instance:   instance,
+			Background: w32.HBRUSH(w32.GetStockObject(w32.BLACK_BRUSH)), // stock, so never to be deleted
+			ClassName:  class,
+		}
+		wc.Size = uint32(unsafe.Sizeof(wc))
+		if w32.RegisterClassEx(&wc) == 0 {
+			return 0, fmt.Errorf("failed to RegisterClassEx:%d", w32.GetLastError())
+		}
+		dimClassRegistered = true
+	}
+	h := w32.CreateWindowEx(w32.WS_EX_LAYERED|w32.WS_EX_TRANSPARENT|w32.WS_EX_TOOLWINDOW|w32.WS_EX_TOPMOST|w32.WS_EX_NOACTIVATE,
+		class, nil, w32.WS_POPUP, 0, 0, 0, 0, 0, 0, instance, nil)
+	if h == 0 {
+		return 0, fmt.Errorf("failed to CreateWindowEx:%d", w32.GetLastError())
+	}
+	if !w32.SetLayeredWindowAttributes(h, 0, byte(config.FocusMode.Opacity*255/100), lwaAlpha) {
+		err := fmt.Errorf("failed to SetLayeredWindowAttributes:%d", w32.GetLastError())
+		w32.DestroyWindow(h)
+		return 0, err
+	}
+	return h, nil
+}
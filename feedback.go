@@ -0,0 +1,100 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// FeedbackConfig is the feedback given after running actions.
+type FeedbackConfig struct {
+	Toast bool `json:"toast"` // notification naming the action and the window
+	Sound bool `json:"sound"` // default system sound
+}
+
+// ActionFeedback overrides the feedback for an action. Unset fields are taken
+// from FeedbackConfig, and the flash from FlashZoneConfig.
+type ActionFeedback struct {
+	Toast *bool `json:"toast"`
+	Sound *bool `json:"sound"`
+	Flash *bool `json:"flash"`
+}
+
+// currentAction is the name of the action of the hotkey running, while it
+// runs, to look its feedback up.
+var currentAction string
+
+// acted records whether the running action moved, resized or restored a
+// window, or toggled a mode (focus, resize or presentation mode), so that no
+// feedback is given for hotkeys that did nothing.
+var acted bool
+
+// feedbackFor returns whether to show a toast, play a sound and flash the zone
+// for the action.
+func feedbackFor(action string) (toast, sound, flash bool) {
+	toast, sound, flash = config.Feedback.Toast, config.Feedback.Sound, config.FlashZone.Enabled
+	o, ok := config.ActionFeedback[action]
+	if !ok {
+		return
+	}
+	if o.Toast != nil {
+		toast = *o.Toast
+	}
+	if o.Sound != nil {
+		sound = *o.Sound
+	}
+	if o.Flash != nil {
+		flash = *o.Flash
+	}
+	return
+}
+
+// withFeedback makes the hotkeys set currentAction while they run, and give
+// the feedback configured for their action names once done.
+func withFeedback(hks []HotKey, overrides map[string]ActionFeedback) []HotKey {
+	known := make(map[string]bool)
+	for i, hk := range hks {
+		known[hk.name] = true
+		name, f := hk.name, hk.callback
+		hks[i].callback = func() {
+			currentAction, acted = name, false
+			defer func() { currentAction = "" }()
+			f()
+			if !acted {
+				fmt.Printf("> %s: no window changed, no feedback\n", name)
+				return
+			}
+			toast, sound, _ := feedbackFor(name)
+			if sound {
+				if why, ok := muted(); ok {
+					fmt.Printf("> sound muted in %s\n", why)
+				} else if !w32.MessageBeep(w32.MB_OK) {
+					fmt.Printf("warn: feedback: failed to MessageBeep:%d\n", w32.GetLastError())
+				}
+			}
+			if toast {
+				notify(fmt.Sprintf("%s: %s", name, w32.GetWindowText(w32.GetForegroundWindow())))
+			}
+		}
+	}
+	for name := range overrides {
+		if !known[name] {
+			fmt.Printf("warn: actionFeedback: unknown or disabled action %q\n", name)
+		}
+	}
+	return hks
+}
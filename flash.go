@@ -0,0 +1,118 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+const (
+	flashClassName = "RectangleWinFlash"
+	flashTimerID   = 1
+	lwaAlpha       = 0x2 // SetLayeredWindowAttributes: use the alpha value
+)
+
+// flashWindow is the click-through outline window shown by flashZone, created
+// on first use.
+var flashWindow w32.HWND
+
+// flashZone briefly shows an outline around the rect (in screen coordinates),
+// if configured, to show where a window went.
+func flashZone(r w32.RECT) {
+	if _, _, flash := feedbackFor(currentAction); !flash {
+		return
+	}
+	if flashWindow == 0 {
+		h, err := createFlashWindow()
+		if err != nil {
+			fmt.Printf("warn: flash: %v\n", err)
+			return
+		}
+		flashWindow = h
+	}
+	t := int(config.FlashZone.Thickness)
+	w, h := int(r.Width()), int(r.Height())
+	// regions are owned by the window once set, so they are not deleted
+	rgn := w32.CreateRectRgn(0, 0, w, h)
+	inner := w32.CreateRectRgn(t, t, w-t, h-t)
+	w32.CombineRgn(rgn, rgn, inner, w32.RGN_DIFF)
+	w32.DeleteObject(w32.HGDIOBJ(inner))
+	if !w32ex.SetWindowRgn(flashWindow, rgn, false) {
+		fmt.Printf("warn: flash: failed to SetWindowRgn:%d\n", w32.GetLastError())
+	}
+	if !w32.SetWindowPos(flashWindow, w32.HWND_TOPMOST, int(r.Left), int(r.Top), w, h, w32.SWP_NOACTIVATE|w32.SWP_SHOWWINDOW) {
+		fmt.Printf("warn: flash: failed to SetWindowPos:%d\n", w32.GetLastError())
+		return
+	}
+	w32.SetTimer(flashWindow, flashTimerID, uint(config.FlashZone.DurationMs), 0)
+}
+
+func createFlashWindow() (w32.HWND, error) {
+	color, err := parseColor(config.FlashZone.Color)
+	if err != nil {
+		return 0, err // already checked by Config.validate
+	}
+	instance := w32.GetModuleHandle("")
+	class := syscall.StringToUTF16Ptr(flashClassName)
+	wc := w32.WNDCLASSEX{
+		WndProc:    syscall.NewCallback(flashWindowProc),
+		Instance:   instance,
+		Background: w32.CreateSolidBrush(color),
+		ClassName:  class,
+	}
+	wc.Size = uint32(unsafe.Sizeof(wc))
+	if w32.RegisterClassEx(&wc) == 0 {
+		return 0, fmt.Errorf("failed to RegisterClassEx:%d", w32.GetLastError())
+	}
+	h := w32.CreateWindowEx(w32.WS_EX_LAYERED|w32.WS_EX_TRANSPARENT|w32.WS_EX_TOOLWINDOW|w32.WS_EX_TOPMOST|w32.WS_EX_NOACTIVATE,
+		class, nil, w32.WS_POPUP, 0, 0, 0, 0, 0, 0, instance, nil)
+	if h == 0 {
+		return 0, fmt.Errorf("failed to CreateWindowEx:%d", w32.GetLastError())
+	}
+	if !w32.SetLayeredWindowAttributes(h, 0, 255, lwaAlpha) {
+		return 0, fmt.Errorf("failed to SetLayeredWindowAttributes:%d", w32.GetLastError())
+	}
+	return h, nil
+}
+
+func flashWindowProc(hwnd w32.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	if msg == w32.WM_TIMER && wParam == flashTimerID {
+		w32ex.KillTimer(hwnd, flashTimerID)
+		w32.ShowWindow(hwnd, w32.SW_HIDE)
+		return 0
+	}
+	return w32.DefWindowProc(hwnd, msg, wParam, lParam)
+}
+
+// parseColor parses a "#RRGGBB" color into a COLORREF.
+func parseColor(s string) (uint32, error) {
+	if len(s) != 7 || !strings.HasPrefix(s, "#") {
+		return 0, fmt.Errorf("invalid color %q, must be like \"#RRGGBB\"", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid color %q, must be like \"#RRGGBB\"", s)
+	}
+	r, g, b := uint32(v>>16)&0xFF, uint32(v>>8)&0xFF, uint32(v)&0xFF
+	return r | g<<8 | b<<16, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+const (
+	foregroundNone        = "none"
+	foregroundAttachInput = "attachInput"
+	foregroundFull        = "full"
+)
+
+// setForeground brings the window to the foreground. Windows refuses this
+// unless the process has received the last input, which it hasn't after e.g.
+// a timer or a tray click, so on failure it falls back to the workarounds
+// allowed by foregroundFallback: attaching to the input of the thread of the
+// foreground window, and then synthesizing an Alt key press.
+func setForeground(hwnd w32.HWND) bool {
+	if w32.SetForegroundWindow(hwnd) && w32.GetForegroundWindow() == hwnd {
+		return true
+	}
+	if config.ForegroundFallback == foregroundNone {
+		fmt.Printf("warn: failed to SetForegroundWindow:%d\n", w32.GetLastError())
+		return false
+	}
+
+	fmt.Printf("> SetForegroundWindow refused for window 0x%x, attaching to the input of the foreground window\n", hwnd)
+	cur := w32ex.GetCurrentThreadId()
+	if fg := w32.GetForegroundWindow(); fg != 0 {
+		tid, _ := w32.GetWindowThreadProcessId(fg)
+		if uint32(tid) != cur && w32ex.AttachThreadInput(cur, uint32(tid), true) {
+			defer w32ex.AttachThreadInput(cur, uint32(tid), false)
+		}
+	}
+	w32ex.BringWindowToTop(hwnd)
+	if w32.SetForegroundWindow(hwnd) && w32.GetForegroundWindow() == hwnd {
+		return true
+	}
+
+	if config.ForegroundFallback == foregroundFull {
+		fmt.Printf("> SetForegroundWindow still refused for window 0x%x, synthesizing an Alt key press\n", hwnd)
+		w32.SendInput(
+			w32.KeyboardInput(w32.KEYBDINPUT{Vk: w32.VK_MENU}),
+			w32.KeyboardInput(w32.KEYBDINPUT{Vk: w32.VK_MENU, Flags: w32.KEYEVENTF_KEYUP}))
+		if w32.SetForegroundWindow(hwnd) && w32.GetForegroundWindow() == hwnd {
+			return true
+		}
+	}
+	fmt.Printf("warn: failed to bring window 0x%x to the foreground:%d\n", hwnd, w32.GetLastError())
+	return false
+}
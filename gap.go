@@ -0,0 +1,111 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// withGap insets the zone by the gap on the edges of the work area, and by
+// half of it elsewhere, so that adjacent zones end up a gap apart. Zones are
+// visible frames, so the gap is between the visible edges of windows whatever
+// the width of their invisible borders. For odd gaps, the left and top edges
+// get the larger half so that the gap between two zones stays exact.
+func withGap(zone, work w32.RECT, gap int32) w32.RECT {
+	if gap == 0 {
+		return zone
+	}
+	inset := func(edge, workEdge, half int32) int32 {
+		if edge == workEdge {
+			return gap
+		}
+		return half
+	}
+	out := w32.RECT{
+		Left:   zone.Left + inset(zone.Left, work.Left, gap-gap/2),
+		Top:    zone.Top + inset(zone.Top, work.Top, gap-gap/2),
+		Right:  zone.Right - inset(zone.Right, work.Right, gap/2),
+		Bottom: zone.Bottom - inset(zone.Bottom, work.Bottom, gap/2),
+	}
+	if out.Width() <= 0 || out.Height() <= 0 {
+		return zone // too small to fit the gap
+	}
+	return out
+}
+
+// withoutGap undoes withGap, returning the zone a window frame was placed in.
+// Frames not placed with a gap get larger by half of it.
+func withoutGap(frame, work w32.RECT, gap int32) w32.RECT {
+	if gap == 0 {
+		return frame
+	}
+	outset := func(edge, workEdge, half int32) int32 {
+		if edge == workEdge {
+			return gap
+		}
+		return half
+	}
+	return w32.RECT{
+		Left:   frame.Left - outset(frame.Left, work.Left+gap, gap-gap/2),
+		Top:    frame.Top - outset(frame.Top, work.Top+gap, gap-gap/2),
+		Right:  frame.Right + outset(frame.Right, work.Right-gap, gap/2),
+		Bottom: frame.Bottom + outset(frame.Bottom, work.Bottom-gap, gap/2),
+	}
+}
+
+// cycleGap changes the gap to the next one in the configured gaps, and snaps
+// the windows on the monitor of the foreground window that are still in the
+// zone RectangleWin placed them in to that zone again with the new gap.
+func cycleGap() (bool, error) {
+	if len(config.Gaps) == 0 {
+		fmt.Println("no gaps configured to cycle")
+		return false, nil
+	}
+	old := config.Gap
+	config.Gap = config.Gaps[0]
+	for i, g := range config.Gaps {
+		if g == old {
+			config.Gap = config.Gaps[(i+1)%len(config.Gaps)]
+			break
+		}
+	}
+	fmt.Printf("> gap: %dpx -> %dpx\n", old, config.Gap)
+	notify(fmt.Sprintf("Gap: %dpx", config.Gap))
+
+	mon := w32.MonitorFromWindow(w32.GetForegroundWindow(), w32.MONITOR_DEFAULTTONEAREST)
+	var changed bool
+	for _, w := range zonableWindows(orderZ, mon) {
+		z, ok := windowZones[w.hwnd]
+		if !ok || z.mon != mon {
+			continue
+		}
+		_, area, err := workArea(w.hwnd)
+		if err != nil {
+			return changed, err
+		}
+		if !matchesZone(w.frame, withGap(mapRect(z.zone, z.work, area), area, old), config.ZoneTolerance) {
+			continue // moved or resized since
+		}
+		ok, err = resize(w.hwnd, func(disp, _ w32.RECT) w32.RECT { return mapRect(z.zone, z.work, disp) })
+		if err != nil {
+			fmt.Printf("warn: gap: window 0x%x: %v\n", w.hwnd, err)
+			continue
+		}
+		changed = changed || ok
+	}
+	return changed, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// testBorders are invisible borders of different apps: none (e.g. Chrome), the
+// win10 default at 96 DPI and at 150%, and a window with uneven borders.
+var testBorders = []w32.RECT{
+	{},
+	{Left: 7, Top: 0, Right: 7, Bottom: 7},
+	{Left: 11, Top: 0, Right: 11, Bottom: 11},
+	{Left: 1, Top: 2, Right: 9, Bottom: 4},
+}
+
+// placeWindow returns the window rect resize gives a window with the borders
+// for the zone, and the visible frame that rect ends up with.
+func placeWindow(work, zone, borders w32.RECT, gap int32) (rect, frame w32.RECT) {
+	rect = withBorders(withGap(zone, work, gap), borders)
+	frame = w32.RECT{
+		Left:   rect.Left + borders.Left,
+		Top:    rect.Top + borders.Top,
+		Right:  rect.Right - borders.Right,
+		Bottom: rect.Bottom - borders.Bottom,
+	}
+	return rect, frame
+}
+
+func TestGapBetweenVisibleFrames(t *testing.T) {
+	work := w32.RECT{Left: -1920, Top: 0, Right: 0, Bottom: 1040}
+	vertical := []struct {
+		name        string
+		top, bottom resizeFunc
+	}{
+		{"halves", topHalf, bottomHalf},
+		{"topTwoThirds+bottomOneThirds", defaultSplit.topTwoThirds, defaultSplit.bottomOneThirds},
+	}
+	for _, gap := range []int32{0, 1, 9, 10} {
+		for _, lb := range testBorders {
+			for _, rb := range testBorders {
+				for _, z := range complementaryZones {
+					t.Run(fmt.Sprintf("%s/gap=%d/borders=%v,%v", z.name, gap, lb, rb), func(t *testing.T) {
+						_, left := placeWindow(work, z.left(work, work), lb, gap)
+						_, right := placeWindow(work, z.right(work, work), rb, gap)
+						if got := right.Left - left.Right; got != gap {
+							t.Errorf("visible gap between the windows = %d, want %d", got, gap)
+						}
+						if left.Left-work.Left != gap || work.Right-right.Right != gap {
+							t.Errorf("visible gaps to the work area = %d and %d, want %d", left.Left-work.Left, work.Right-right.Right, gap)
+						}
+						if left.Top-work.Top != gap || work.Bottom-right.Bottom != gap {
+							t.Errorf("visible gaps to the top and bottom = %d and %d, want %d", left.Top-work.Top, work.Bottom-right.Bottom, gap)
+						}
+					})
+				}
+				for _, z := range vertical {
+					t.Run(fmt.Sprintf("%s/gap=%d/borders=%v,%v", z.name, gap, lb, rb), func(t *testing.T) {
+						_, top := placeWindow(work, z.top(work, work), lb, gap)
+						_, bottom := placeWindow(work, z.bottom(work, work), rb, gap)
+						if got := bottom.Top - top.Bottom; got != gap {
+							t.Errorf("visible gap between the windows = %d, want %d", got, gap)
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestWithoutGapRecoversZone(t *testing.T) {
+	work := w32.RECT{Left: 0, Top: 0, Right: 1920, Bottom: 1040}
+	for _, gap := range []int32{1, 9, 10} {
+		for _, borders := range testBorders {
+			for _, z := range complementaryZones {
+				for _, zone := range []w32.RECT{z.left(work, work), z.right(work, work)} {
+					rect, frame := placeWindow(work, zone, borders, gap)
+					if got := invisibleBorders(rect, frame); got != borders {
+						t.Errorf("invisibleBorders(%v, %v) = %v, want %v", rect, frame, got, borders)
+					}
+					if got := withoutGap(frame, work, gap); got != zone {
+						t.Errorf("%s/gap=%d/borders=%v: withoutGap(%v) = %v, want the zone %v", z.name, gap, borders, frame, got, zone)
+					}
+				}
+			}
+		}
+	}
+}
@@ -16,20 +16,158 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ahmetb/RectangleWin/w32ex"
 	"github.com/gonutz/w32/v2"
 )
 
+// wmRunFunc is posted to the main thread to run the funcs queued with
+// runOnMainThread.
+const wmRunFunc = w32.WM_APP + 1
+
 var (
 	hotkeyRegistrations = make(map[int]*HotKey)
+
+	mainThreadID    uint32
+	mainThreadFuncs = make(chan func(), 64)
 )
 
 type HotKey struct {
 	id, mod, vk int
+	name        string // action name used to rebind the hotkey in the config
 	callback    func()
 }
 
+// HotKeyBinding is a key combination configured for an action.
+type HotKeyBinding struct {
+	Mods []string `json:"mods"` // see modKeysByName
+	Key  string   `json:"key"`  // see parseKey
+}
+
+// ModifierRemap replaces a modifier in the default hotkeys with others.
+type ModifierRemap struct {
+	From string   `json:"from"` // see modKeysByName
+	To   []string `json:"to"`
+}
+
+func (r ModifierRemap) parse() (from, to int, err error) {
+	if from, err = parseModifiers([]string{r.From}); err != nil {
+		return 0, 0, err
+	}
+	if to, err = parseModifiers(r.To); err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+func (b HotKeyBinding) parse() (mod, vk int, err error) {
+	if mod, err = parseModifiers(b.Mods); err != nil {
+		return 0, 0, err
+	}
+	if vk, err = parseKey(b.Key); err != nil {
+		return 0, 0, err
+	}
+	return mod, vk, nil
+}
+
+// bindHotKeys replaces the key combinations of the hotkeys with the ones
+// configured for their action names. Whether a hotkey repeats when held down
+// is kept as is.
+func bindHotKeys(hks []HotKey, bindings map[string]HotKeyBinding) []HotKey {
+	known := make(map[string]bool)
+	for i, hk := range hks {
+		known[hk.name] = true
+		b, ok := bindings[hk.name]
+		if !ok {
+			continue
+		}
+		mod, vk, err := b.parse()
+		if err != nil {
+			// already checked by Config.validate
+			panic(err)
+		}
+		hks[i].mod = mod | hk.mod&MOD_NOREPEAT
+		hks[i].vk = vk
+		fmt.Printf("> hotkey %s bound to %s\n", hk.name, hks[i].Describe())
+	}
+	for name := range bindings {
+		if !known[name] {
+			fmt.Printf("warn: hotkeys: unknown or disabled action %q\n", name)
+		}
+	}
+	return hks
+}
+
+// withCooldowns makes the hotkeys with a cooldown configured for their action
+// names ignore being triggered again before the cooldown is over.
+func withCooldowns(hks []HotKey, cooldowns map[string]int) []HotKey {
+	known := make(map[string]bool)
+	for i, hk := range hks {
+		known[hk.name] = true
+		ms := cooldowns[hk.name]
+		if ms == 0 {
+			continue
+		}
+		cooldown, name, f := time.Duration(ms)*time.Millisecond, hk.name, hk.callback
+		var last time.Time
+		hks[i].callback = func() {
+			if since := time.Since(last); since < cooldown {
+				fmt.Printf("> %s: ignored, %v since the last run is within its cooldown (%v)\n", name, since, cooldown)
+				return
+			}
+			last = time.Now()
+			f()
+		}
+	}
+	for name := range cooldowns {
+		if !known[name] {
+			fmt.Printf("warn: cooldowns: unknown or disabled action %q\n", name)
+		}
+	}
+	return hks
+}
+
+// remapModifier replaces the modifier with the others in the hotkeys using it.
+func remapModifier(hks []HotKey, r ModifierRemap) []HotKey {
+	if r.From == "" {
+		return hks
+	}
+	from, to, err := r.parse()
+	if err != nil {
+		// already checked by Config.validate
+		panic(err)
+	}
+	for i, hk := range hks {
+		if hk.mod&from == 0 {
+			continue
+		}
+		hks[i].mod = hk.mod&^from | to
+		fmt.Printf("> hotkey %s remapped to %s\n", hk.name, hks[i].Describe())
+	}
+	return hks
+}
+
+// dropConflictingHotKeys removes the hotkeys with the same key combination as
+// a hotkey before them, and returns the descriptions of the conflicts.
+func dropConflictingHotKeys(hks []HotKey) ([]HotKey, []string) {
+	type combo struct{ mod, vk int }
+	seen := make(map[combo]string)
+	var out []HotKey
+	var conflicts []string
+	for _, hk := range hks {
+		c := combo{hk.mod &^ MOD_NOREPEAT, hk.vk}
+		if other, ok := seen[c]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s, already used by %s)", hk.Describe(), hk.name, other))
+			fmt.Printf("warn: hotkey %s of %s is already used by %s\n", hk.Describe(), hk.name, other)
+			continue
+		}
+		seen[c] = hk.name
+		out = append(out, hk)
+	}
+	return out, conflicts
+}
+
 func (h HotKey) String() string { return fmt.Sprintf("mod=0x%x,vk=%d", h.mod, h.vk) }
 
 func (h HotKey) Describe() string {
@@ -54,6 +192,26 @@ func (h HotKey) Describe() string {
 	return out
 }
 
+// reverseHotKeys returns the hotkeys running the reverse callbacks of the
+// hotkeys, by name. They are the same key combinations with the configured
+// reverse modifier added, and their ids are the ids of the hotkeys + 100.
+func reverseHotKeys(hks []HotKey, reverse map[string]func()) []HotKey {
+	mod, _ := parseModifiers([]string{config.ReverseModifier}) // already checked by Config.validate
+	var out []HotKey
+	for _, hk := range hks {
+		f, ok := reverse[hk.name]
+		if !ok {
+			continue
+		}
+		if hk.mod&mod != 0 {
+			fmt.Printf("warn: %s already uses %s, it has no reverse hotkey\n", hk.Describe(), config.ReverseModifier)
+			continue
+		}
+		out = append(out, HotKey{id: hk.id + 100, name: hk.name + "Reverse", mod: hk.mod | mod, vk: hk.vk, callback: f})
+	}
+	return out
+}
+
 func RegisterHotKey(h HotKey) bool {
 	if _, ok := hotkeyRegistrations[h.id]; ok {
 		panic("hotkey id already registered") // TODO ok for now
@@ -65,8 +223,29 @@ func RegisterHotKey(h HotKey) bool {
 	return ok
 }
 
+// runOnMainThread schedules f to run on the thread running msgLoop, which
+// owns the hotkeys and the state of window operations.
+func runOnMainThread(f func()) {
+	mainThreadFuncs <- f
+	if !w32ex.PostThreadMessage(mainThreadID, wmRunFunc, 0, 0) {
+		fmt.Printf("warn: PostThreadMessage failed:%d\n", w32.GetLastError())
+	}
+}
+
+func runMainThreadFuncs() {
+	for {
+		select {
+		case f := <-mainThreadFuncs:
+			f()
+		default:
+			return
+		}
+	}
+}
+
 func msgLoop() error {
 	defer fmt.Println("event loop finished")
+	runMainThreadFuncs() // in case any were queued before the message queue existed
 	for {
 		var m w32.MSG
 		c := w32.GetMessage(&m, 0, 0, 0)
@@ -83,6 +262,8 @@ func msgLoop() error {
 			}
 			fmt.Printf("trace: hotkey id=%d (%s)\n", m.WParam, h)
 			h.callback()
+		} else if m.Message == wmRunFunc {
+			runMainThreadFuncs()
 		} else {
 			fmt.Printf("unhandled message received:0x%x %d\n", m.Message, m.Message)
 			w32.TranslateMessage(&m)
@@ -0,0 +1,108 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+)
+
+const (
+	llkhfInjected = 0x10 // KBDLLHOOKSTRUCT.Flags: the event was injected
+
+	// vkUnassigned is a virtual key code with no meaning, injected so that the
+	// shell sees the Win key being used in a chord and does not open the Start
+	// menu when it is released.
+	vkUnassigned = 0xE8
+)
+
+var (
+	keyboardHook  w32.HHOOK
+	hookedHotKeys []HotKey
+	hookHeldVK    w32.DWORD // key of the hotkey held down, to honor MOD_NOREPEAT
+)
+
+// installKeyboardHook handles the hotkeys with a low-level keyboard hook
+// rather than RegisterHotKey. The triggering keys are swallowed, so the shell
+// never sees the chord.
+func installKeyboardHook(hks []HotKey) error {
+	keyboardHook = w32.SetWindowsHookEx(w32.WH_KEYBOARD_LL, keyboardHookProc, w32.GetModuleHandle(""), 0)
+	if keyboardHook == 0 {
+		return fmt.Errorf("failed to SetWindowsHookEx:%d", w32.GetLastError())
+	}
+	hookedHotKeys = hks
+	return nil
+}
+
+func keyboardHookProc(code int, wParam w32.WPARAM, lParam w32.LPARAM) w32.LRESULT {
+	if code < 0 {
+		return w32.CallNextHookEx(keyboardHook, code, wParam, lParam)
+	}
+	kb := *(**w32.KBDLLHOOKSTRUCT)(unsafe.Pointer(&lParam))
+	if kb.Flags&llkhfInjected != 0 {
+		return w32.CallNextHookEx(keyboardHook, code, wParam, lParam)
+	}
+	switch wParam {
+	case w32.WM_KEYDOWN, w32.WM_SYSKEYDOWN:
+		mod := pressedModifiers()
+		for _, h := range hookedHotKeys {
+			if w32.DWORD(h.vk) != kb.VkCode || h.mod&^MOD_NOREPEAT != mod {
+				continue
+			}
+			repeat := hookHeldVK == kb.VkCode
+			hookHeldVK = kb.VkCode
+			if repeat && h.mod&MOD_NOREPEAT != 0 {
+				return 1
+			}
+			suppressStartMenu()
+			fmt.Printf("trace: hooked hotkey id=%d (%s)\n", h.id, h)
+			runOnMainThread(h.callback) // hook procs must return quickly
+			return 1
+		}
+	case w32.WM_KEYUP, w32.WM_SYSKEYUP:
+		if hookHeldVK == kb.VkCode {
+			hookHeldVK = 0
+			return 1
+		}
+	}
+	return w32.CallNextHookEx(keyboardHook, code, wParam, lParam)
+}
+
+// pressedModifiers returns the MOD_* flags of the modifier keys held down.
+func pressedModifiers() int {
+	down := func(vk int) bool { return w32.GetAsyncKeyState(vk)&0x8000 != 0 }
+	var mod int
+	if down(w32.VK_LWIN) || down(w32.VK_RWIN) {
+		mod |= MOD_WIN
+	}
+	if down(w32.VK_CONTROL) {
+		mod |= MOD_CONTROL
+	}
+	if down(w32.VK_MENU) {
+		mod |= MOD_ALT
+	}
+	if down(w32.VK_SHIFT) {
+		mod |= MOD_SHIFT
+	}
+	return mod
+}
+
+func suppressStartMenu() {
+	w32.SendInput(
+		w32.KeyboardInput(w32.KEYBDINPUT{Vk: vkUnassigned}),
+		w32.KeyboardInput(w32.KEYBDINPUT{Vk: vkUnassigned, Flags: w32.KEYEVENTF_KEYUP}))
+}
@@ -1,5 +1,16 @@
 package main
 
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
 // https://docs.microsoft.com/en-us/windows/win32/api/winuser/nf-winuser-registerhotkey
 const (
 	MOD_ALT      = 0x0001
@@ -16,6 +27,109 @@ var modKeyNames = map[int]string{
 	MOD_WIN:     "Win",
 }
 
+// modKeysByName maps the modifier names accepted in the config to modifiers.
+var modKeysByName = map[string]int{
+	"alt":     MOD_ALT,
+	"ctrl":    MOD_CONTROL,
+	"control": MOD_CONTROL,
+	"shift":   MOD_SHIFT,
+	"win":     MOD_WIN,
+}
+
+// keysByName maps the key names accepted in the config to virtual-key codes.
+var keysByName = func() map[string]int {
+	m := map[string]int{
+		"backspace":    w32.VK_BACK,
+		"back":         w32.VK_BACK,
+		"tab":          w32.VK_TAB,
+		"enter":        w32.VK_RETURN,
+		"return":       w32.VK_RETURN,
+		"pause":        w32.VK_PAUSE,
+		"escape":       w32.VK_ESCAPE,
+		"esc":          w32.VK_ESCAPE,
+		"space":        w32.VK_SPACE,
+		"pageup":       w32.VK_PRIOR,
+		"pagedown":     w32.VK_NEXT,
+		"end":          w32.VK_END,
+		"home":         w32.VK_HOME,
+		"left":         w32.VK_LEFT,
+		"up":           w32.VK_UP,
+		"right":        w32.VK_RIGHT,
+		"down":         w32.VK_DOWN,
+		"printscreen":  w32.VK_SNAPSHOT,
+		"insert":       w32.VK_INSERT,
+		"ins":          w32.VK_INSERT,
+		"delete":       w32.VK_DELETE,
+		"del":          w32.VK_DELETE,
+		"multiply":     w32.VK_MULTIPLY,
+		"add":          w32.VK_ADD,
+		"subtract":     w32.VK_SUBTRACT,
+		"decimal":      w32.VK_DECIMAL,
+		"divide":       w32.VK_DIVIDE,
+		"semicolon":    w32.VK_OEM_1,
+		"slash":        w32.VK_OEM_2,
+		"backtick":     w32.VK_OEM_3,
+		"leftbracket":  w32.VK_OEM_4,
+		"backslash":    w32.VK_OEM_5,
+		"rightbracket": w32.VK_OEM_6,
+		"quote":        w32.VK_OEM_7,
+		"plus":         w32.VK_OEM_PLUS,
+		"equals":       w32.VK_OEM_PLUS,
+		"comma":        w32.VK_OEM_COMMA,
+		"minus":        w32.VK_OEM_MINUS,
+		"period":       w32.VK_OEM_PERIOD,
+	}
+	for c := '0'; c <= '9'; c++ {
+		m[string(c)] = int(c)
+		m["numpad"+string(c)] = w32.VK_NUMPAD0 + int(c-'0')
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		m[string(c)] = w32ex.VK_N_A + int(c-'a')
+	}
+	for i := 1; i <= 24; i++ {
+		m[fmt.Sprintf("f%d", i)] = w32.VK_F1 + i - 1
+	}
+	return m
+}()
+
+// parseKey returns the virtual-key code for a key name from keysByName, or a
+// hex virtual-key code like "0x43".
+func parseKey(s string) (int, error) {
+	if vk, ok := keysByName[strings.ToLower(s)]; ok {
+		return vk, nil
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		if vk, err := strconv.ParseUint(s[2:], 16, 8); err == nil && vk != 0 {
+			return int(vk), nil
+		}
+		return 0, fmt.Errorf("invalid virtual-key code %q (must be between 0x01 and 0xFF)", s)
+	}
+	return 0, fmt.Errorf("unknown key %q, valid key names are: %s", s, strings.Join(sortedKeys(keysByName), " "))
+}
+
+// parseModifiers returns the modifiers for a list of names from
+// modKeysByName.
+func parseModifiers(names []string) (int, error) {
+	var mod int
+	for _, n := range names {
+		m, ok := modKeysByName[strings.ToLower(n)]
+		if !ok {
+			return 0, fmt.Errorf("unknown modifier %q, valid modifiers are: %s", n, strings.Join(sortedKeys(modKeysByName), " "))
+		}
+		mod |= m
+	}
+	return mod, nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
 // https://docs.microsoft.com/en-us/windows/win32/inputdev/virtual-key-codes
 var keyNames = map[int]string{
 	0x01: `Left mouse button`,
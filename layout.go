@@ -0,0 +1,175 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+	"golang.org/x/sys/windows"
+)
+
+// AppZone places a window of an app on a monitor, in percent of its work
+// area.
+type AppZone struct {
+	App     AppMatcher `json:"app"`
+	Monitor string     `json:"monitor,omitempty"` // device name, the window's monitor if empty or not connected
+	Left    float64    `json:"left"`
+	Top     float64    `json:"top"`
+	Width   float64    `json:"width"`
+	Height  float64    `json:"height"`
+}
+
+// rect returns the zone in screen coordinates within the work area.
+func (z AppZone) rect(work w32.RECT) w32.RECT {
+	pct := func(base, size int32, v float64) int32 { return base + int32(math.Round(float64(size)*v/100)) }
+	return w32.RECT{
+		Left:   pct(work.Left, work.Width(), z.Left),
+		Top:    pct(work.Top, work.Height(), z.Top),
+		Right:  pct(work.Left, work.Width(), z.Left+z.Width),
+		Bottom: pct(work.Top, work.Height(), z.Top+z.Height),
+	}
+}
+
+// applyAppZones places the open windows matching the configured app zones.
+// Each zone is applied to the next window of its app in Z-order that no
+// earlier zone was applied to, so that an app can have a zone per window.
+// Zones without a matching window are skipped.
+func applyAppZones() (bool, error) {
+	defer restoreFocus(w32.GetForegroundWindow())
+	windows := zonableWindows(orderZ, 0)
+	placed := make(map[w32.HWND]bool)
+	var resized bool
+	appZones := config.AppZones
+	if max := config.MaxBulkWindows; max > 0 && len(appZones) > max {
+		fmt.Printf("warn: app zones: %d zones, more than maxBulkWindows=%d, skipping %d\n", len(appZones), max, len(appZones)-max)
+		notify(fmt.Sprintf("App zones: skipped %d of %d zones (maxBulkWindows is %d).", len(appZones)-max, len(appZones), max))
+		appZones = appZones[:max]
+	}
+	for _, z := range appZones {
+		var hwnd w32.HWND
+		for _, w := range windows {
+			if !placed[w.hwnd] && z.App.matches(w.hwnd) {
+				hwnd = w.hwnd
+				break
+			}
+		}
+		if hwnd == 0 {
+			fmt.Printf("> app zone: no window of app (%s), skipping\n", z.App)
+			continue
+		}
+		placed[hwnd] = true
+		if mon := findMonitor(z.Monitor); mon != 0 && mon != w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST) {
+			// so that resize works out the borders, DPI and gap on the monitor of the zone
+			moved, err := moveToMonitor(hwnd, mon)
+			if err != nil {
+				return resized, fmt.Errorf("window 0x%x: %w", hwnd, err)
+			}
+			resized = resized || moved
+		}
+		ok, err := resize(hwnd, func(disp, _ w32.RECT) w32.RECT {
+			r := z.rect(disp)
+			fmt.Printf("> app zone: window 0x%x (%s) to %#v\n", hwnd, z.App, r)
+			return r
+		})
+		if err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", hwnd, err)
+		}
+		resized = resized || ok
+	}
+	return resized, nil
+}
+
+// dumpLayout writes the positions of the open windows as an "appZones" config
+// snippet to layout.json next to the config file, and if configured, copies
+// it to the clipboard.
+func dumpLayout() error {
+	var out struct {
+		AppZones []AppZone `json:"appZones"`
+	}
+	for _, w := range zonableWindows(orderZ, 0) {
+		app := AppMatcher{Exe: filepath.Base(windowExe(w.hwnd))}
+		if app.Exe == "." {
+			app.Exe = ""
+			app.Class, _ = w32.GetClassName(w.hwnd)
+		}
+		mon, work, err := workArea(w.hwnd)
+		if err != nil {
+			return err
+		}
+		zone := withoutGap(w.frame, work, config.Gap) // resize puts the gap back
+		pct := func(v, size int32) float64 { return math.Round(float64(v)*1000/float64(size)) / 10 }
+		out.AppZones = append(out.AppZones, AppZone{
+			App:     app,
+			Monitor: monitorDeviceName(mon),
+			Left:    pct(zone.Left-work.Left, work.Width()),
+			Top:     pct(zone.Top-work.Top, work.Height()),
+			Width:   pct(zone.Width(), work.Width()),
+			Height:  pct(zone.Height(), work.Height()),
+		})
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	d, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d, 0o755); err != nil {
+		return err
+	}
+	p := filepath.Join(d, "layout.json")
+	if err := os.WriteFile(p, b, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("dumped the layout of %d window(s) to %s\n", len(out.AppZones), p)
+	if config.DumpLayoutToClipboard {
+		if err := setClipboardText(string(b)); err != nil {
+			return err
+		}
+	}
+	notify(fmt.Sprintf("Saved the layout of %d window(s) to %s.", len(out.AppZones), p))
+	return nil
+}
+
+func setClipboardText(s string) error {
+	if !w32.OpenClipboard(0) {
+		return fmt.Errorf("failed to OpenClipboard:%d", w32.GetLastError())
+	}
+	defer w32.CloseClipboard()
+	if !w32.EmptyClipboard() {
+		return fmt.Errorf("failed to EmptyClipboard:%d", w32.GetLastError())
+	}
+	text := windows.StringToUTF16(strings.ReplaceAll(s, "\n", "\r\n"))
+	size := uint32(len(text) * 2)
+	mem := w32.GlobalAlloc(w32.GMEM_MOVEABLE, size)
+	if mem == 0 {
+		return fmt.Errorf("failed to GlobalAlloc:%d", w32.GetLastError())
+	}
+	copy(unsafe.Slice((*uint16)(w32.GlobalLock(mem)), len(text)), text)
+	w32.GlobalUnlock(mem)
+	if w32.SetClipboardData(w32.CF_UNICODETEXT, w32.HANDLE(mem)) == 0 {
+		w32.GlobalFree(mem)
+		return fmt.Errorf("failed to SetClipboardData:%d", w32.GetLastError())
+	}
+	return nil
+}
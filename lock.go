@@ -0,0 +1,203 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/getlantern/systray"
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// lockDelay is how long after a locked window moved it is snapped back, so
+// that apps moving it in several steps are done.
+const lockDelay = 250 * time.Millisecond
+
+// maxLockedWindows is how many windows can be locked at once (and are listed
+// in the tray menu).
+const maxLockedWindows = 9
+
+// lockedWindow is a window kept in its zone by RectangleWin.
+type lockedWindow struct {
+	hwnd    w32.HWND
+	zone    placedZone
+	hook    w32.HANDLE
+	pending bool // snapping back is scheduled
+}
+
+var (
+	lockedWindows   []*lockedWindow
+	lockedMenuItems []*systray.MenuItem
+)
+
+var lockCallback = syscall.NewCallback(func(hook, event, hwnd, idObject, idChild, thread, time uintptr) uintptr {
+	if int32(idObject) != w32ex.OBJID_WINDOW || int32(idChild) != w32ex.CHILDID_SELF {
+		return 0
+	}
+	if l := findLocked(w32.HWND(hwnd)); l != nil {
+		onLockedWindowMoved(l)
+	}
+	return 0
+})
+
+// toggleLock locks the window to the zone it is in, or unlocks it. Whenever a
+// locked window is moved or resized, it is snapped back to the zone.
+func toggleLock(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	if l := findLocked(hwnd); l != nil {
+		unlock(l)
+		notify(fmt.Sprintf("Unlocked %q.", w32.GetWindowText(hwnd)))
+		return true, nil
+	}
+	pruneLocked()
+	if len(lockedWindows) >= maxLockedWindows {
+		notify(fmt.Sprintf("Can't lock more than %d windows, unlock one first.", maxLockedWindows))
+		return false, nil
+	}
+	mon, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	tid, pid := w32.GetWindowThreadProcessId(hwnd)
+	hook := w32ex.SetWinEventHookForThread(w32ex.EVENT_OBJECT_LOCATIONCHANGE, w32ex.EVENT_OBJECT_LOCATIONCHANGE, lockCallback, uint32(pid), uint32(tid))
+	if hook == 0 {
+		return false, fmt.Errorf("failed to SetWinEventHook:%d", w32.GetLastError())
+	}
+	lockedWindows = append(lockedWindows, &lockedWindow{hwnd: hwnd, zone: placedZone{mon: mon, work: area, zone: withoutGap(frame, area, config.Gap)}, hook: hook})
+	fmt.Printf("> locked window 0x%x %q to %#v\n", hwnd, w32.GetWindowText(hwnd), frame)
+	updateLockedMenu()
+	notify(fmt.Sprintf("Locked %q to its zone.", w32.GetWindowText(hwnd)))
+	return true, nil
+}
+
+// onLockedWindowMoved schedules snapping the window back to its zone, unless
+// it is already scheduled.
+func onLockedWindowMoved(l *lockedWindow) {
+	if l.pending {
+		return
+	}
+	l.pending = true
+	go func() {
+		time.Sleep(lockDelay)
+		runOnMainThread(func() { enforceLock(l) })
+	}()
+}
+
+// enforceLock snaps the locked window back to its zone, if it is out of it.
+// While the left mouse button is down, which is while the user drags the
+// window, it waits for the drag to end.
+func enforceLock(l *lockedWindow) {
+	l.pending = false
+	if findLocked(l.hwnd) != l {
+		return
+	}
+	if !w32.IsWindow(l.hwnd) {
+		unlock(l)
+		return
+	}
+	if w32.GetAsyncKeyState(w32.VK_LBUTTON)&0x8000 != 0 {
+		onLockedWindowMoved(l)
+		return
+	}
+	if w32ex.IsIconic(l.hwnd) || presentation != nil {
+		return
+	}
+	frame, err := visibleFrame(l.hwnd)
+	if err != nil {
+		fmt.Printf("warn: lock: %v\n", err)
+		return
+	}
+	_, area, err := workArea(l.hwnd)
+	if err != nil {
+		fmt.Printf("warn: lock: %v\n", err)
+		return
+	}
+	z := l.zone
+	if matchesZone(frame, withGap(mapRect(z.zone, z.work, area), area, config.Gap), config.ZoneTolerance) {
+		return
+	}
+	fmt.Printf("> locked window 0x%x %q moved, snapping it back\n", l.hwnd, w32.GetWindowText(l.hwnd))
+	if _, err := resize(l.hwnd, func(disp, _ w32.RECT) w32.RECT { return mapRect(z.zone, z.work, disp) }); err != nil {
+		fmt.Printf("warn: lock: %v\n", err)
+	}
+}
+
+func findLocked(hwnd w32.HWND) *lockedWindow {
+	for _, l := range lockedWindows {
+		if l.hwnd == hwnd {
+			return l
+		}
+	}
+	return nil
+}
+
+func unlock(l *lockedWindow) {
+	if !w32ex.UnhookWinEvent(l.hook) {
+		fmt.Printf("warn: failed to UnhookWinEvent:%d\n", w32.GetLastError())
+	}
+	var out []*lockedWindow
+	for _, v := range lockedWindows {
+		if v != l {
+			out = append(out, v)
+		}
+	}
+	lockedWindows = out
+	fmt.Printf("> unlocked window 0x%x\n", l.hwnd)
+	updateLockedMenu()
+}
+
+// pruneLocked unlocks the locked windows that were closed.
+func pruneLocked() {
+	for _, l := range lockedWindows {
+		if !w32.IsWindow(l.hwnd) {
+			unlock(l)
+		}
+	}
+}
+
+func unlockAll() {
+	for _, l := range lockedWindows {
+		unlock(l)
+	}
+}
+
+// unlockMenuItem unlocks the window listed at the index of the tray menu.
+func unlockMenuItem(i int) {
+	if i < len(lockedWindows) {
+		unlock(lockedWindows[i])
+	}
+}
+
+func updateLockedMenu() {
+	for i, m := range lockedMenuItems {
+		if i < len(lockedWindows) {
+			m.SetTitle(w32.GetWindowText(lockedWindows[i].hwnd))
+			m.Show()
+		} else {
+			m.Hide()
+		}
+	}
+}
@@ -19,11 +19,13 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"reflect"
 	"runtime"
+	"time"
 
 	"github.com/getlantern/systray"
 	"github.com/gonutz/w32/v2"
@@ -31,10 +33,46 @@ import (
 	"github.com/ahmetb/RectangleWin/w32ex"
 )
 
-var lastResized w32.HWND
+var (
+	lastResized w32.HWND
+	edgeCycles  = newCycleState(cycleNames, cycleZone)
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+var verbose = flag.Bool("verbose", false, "print the monitors and settings found on startup")
+
+// resetWindowState forgets everything RectangleWin tracks about windows, as if
+// it was just launched. Windows themselves are left untouched.
+func resetWindowState() {
+	lastResized = 0
+	edgeCycles.reset()
+	recentWindows = nil
+	focusedWindows = [2]w32.HWND{}
+	monitorMove = nil
+	placedWindows = make(map[w32.HWND]bool)
+	windowZones = make(map[w32.HWND]placedZone)
+	previousZones = make(map[w32.HWND]placedZone)
+	slotTurn = 0
+	minimizedWindows = nil
+	monitorDesktops = make(map[w32.HMONITOR][]w32.HWND)
+	lastDesktop = 0
+	maximizedPlacements = make(map[w32.HWND]w32.WINDOWPLACEMENT)
+	zoomedWindows = make(map[w32.HWND]zoomedWindow)
+	presetOrders = make(map[w32.HMONITOR][]w32.HWND)
+	shownWindows = make(map[w32.HWND]bool)
+	invalidateTaskbars()
+	if config.NewWindowsUnderCursor {
+		rememberExistingWindows()
+	}
+	unlockAll()
+}
 
 func main() {
+	flag.Parse()
 	runtime.LockOSThread() // since we bind hotkeys etc that need to dispatch their message here
+	mainThreadID = w32ex.GetCurrentThreadId()
 	if !w32ex.SetProcessDPIAware() {
 		panic("failed to set DPI aware")
 	}
@@ -43,54 +81,64 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("autorun enabled=%v\n", autorun)
-	printMonitors()
+	if *verbose {
+		fmt.Printf("autorun enabled=%v\n", autorun)
+	}
+
+	if c, err := loadConfig(); err != nil {
+		fmt.Printf("warn: config: %v\n", err)
+		showMessageBox(fmt.Sprintf("Failed to load the configuration, using the defaults.\n\n%v", err))
+	} else {
+		config = c
+	}
+
+	if *verbose {
+		printMonitors()
+		fmt.Printf("maximize mode: %s\n", maximizeModeDescriptions[config.MaximizeMode])
+	}
 
-	edgeFuncs := [][]resizeFunc{
-		{leftHalf, leftTwoThirds, leftOneThirds},
-		{rightHalf, rightTwoThirds, rightOneThirds},
-		{topHalf, topTwoThirds, topOneThirds},
-		{bottomHalf, bottomTwoThirds, bottomOneThirds},
-		{leftOneThirds, middleThirds, rightOneThirds},
+	if err := loadSlots(); err != nil {
+		fmt.Printf("warn: slots: %v\n", err)
 	}
-	edgeFuncTurn := make([]int, len(edgeFuncs))
 
-	cycleFuncs := func(funcs [][]resizeFunc, turns *[]int, i int) {
+	cycleFuncs := func(state *cycleState, i int, reverse bool) {
 		hwnd := w32.GetForegroundWindow()
 		if hwnd == 0 {
 			panic("foreground window is NULL")
 		}
-		if lastResized != hwnd {
-			*turns = make([]int, len(edgeFuncs)) // reset
-		}
-		if _, err := resize(hwnd, funcs[i][(*turns)[i]%len(funcs[i])]); err != nil {
+		cycle := cycleZones(hwnd, i)
+		j := state.advance(hwnd, i, len(cycle), reverse)
+		if _, err := resize(hwnd, cycle[j]); err != nil {
 			fmt.Printf("warn: resize: %v\n", err)
 			return
 		}
-		(*turns)[i]++
-		for j := 0; j < len(*turns); j++ {
-			if j != i {
-				(*turns)[j] = 0
-			}
-		}
+		state.moved(hwnd, i, j)
+		fmt.Printf("> %s\n", state)
 	}
 
-	cycleEdgeFuncs := func(i int) { cycleFuncs(edgeFuncs, &edgeFuncTurn, i) }
+	cycleEdgeFuncs := func(i int) { cycleFuncs(edgeCycles, i, false) }
+	reverseCycles := make(map[string]func())
+	for i, name := range cycleNames {
+		i := i
+		reverseCycles[name] = func() { cycleFuncs(edgeCycles, i, true) }
+	}
 
 	hks := []HotKey{
-		{id: 1, mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_S, callback: func() { cycleEdgeFuncs(0) }},
-		{id: 2, mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_F, callback: func() { cycleEdgeFuncs(1) }},
-		{id: 3, mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_E, callback: func() { cycleEdgeFuncs(2) }},
-		{id: 4, mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_D, callback: func() { cycleEdgeFuncs(3) }},
-		{id: 50, mod: MOD_ALT | MOD_WIN, vk: w32.VK_SPACE, callback: func() {
-			lastResized = 0 // cause edgeFuncTurn to be reset
+		{id: 1, name: "cycleLeft", mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_S, callback: func() { cycleEdgeFuncs(0) }},
+		{id: 2, name: "cycleRight", mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_F, callback: func() { cycleEdgeFuncs(1) }},
+		{id: 3, name: "cycleTop", mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_E, callback: func() { cycleEdgeFuncs(2) }},
+		{id: 4, name: "cycleBottom", mod: MOD_ALT | MOD_WIN | MOD_CONTROL | MOD_NOREPEAT, vk: w32ex.VK_N_D, callback: func() { cycleEdgeFuncs(3) }},
+		{id: 50, name: "maximize", mod: MOD_ALT | MOD_WIN, vk: w32.VK_SPACE, callback: func() {
 			if err := maximize(); err != nil {
 				fmt.Printf("warn: maximize: %v\n", err)
-				return
 			}
+			lastResized = 0 // cause cycleSlots to be reset
+			edgeCycles.reset()
 		}},
-		{id: 51, mod: MOD_ALT | MOD_WIN, vk: w32.VK_BACK, callback: func() { cycleEdgeFuncs(4) }},
-		{id: 52, mod: MOD_ALT | MOD_WIN, vk: w32.VK_DELETE, callback: func() {
+		{id: 90, name: "toggleZoom", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32.VK_SPACE, callback: onForeground("zoom", toggleZoom)},
+		{id: 51, name: "cycleThirds", mod: MOD_ALT | MOD_WIN, vk: w32.VK_BACK, callback: func() { cycleEdgeFuncs(4) }},
+		{id: 82, name: "cycleCorners", mod: MOD_ALT | MOD_WIN, vk: w32ex.VK_N_C, callback: func() { cycleEdgeFuncs(5) }},
+		{id: 52, name: "nextMonitor", mod: MOD_ALT | MOD_WIN, vk: w32.VK_DELETE, callback: func() {
 			hwnd := w32.GetForegroundWindow()
 			if hwnd == 0 {
 				panic("foreground window is NULL")
@@ -100,14 +148,138 @@ func main() {
 				return
 			}
 		}},
+		{id: 53, name: "balance", mod: MOD_ALT | MOD_WIN, vk: w32.VK_OEM_PLUS, callback: onForeground("balance", balance)},
+		{id: 55, name: "heroStack", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_H, callback: onForeground("hero stack", heroStack)},
+		{id: 97, name: "broadcastZone", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32ex.VK_N_G, callback: onForeground("broadcast zone", broadcastZone)},
+		{id: 204, name: "spanMonitors", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32.VK_UP, callback: onForeground("span monitors", spanMonitors)},
+		{id: 200, name: "tileRows", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32.VK_DOWN, callback: onForeground("tile rows", tileRows)},
+		{id: 89, name: "gather", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_G, callback: onForeground("gather", gather)},
+	}
+	hks = append(hks, HotKey{id: 57, name: "restoreMinimized", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_Z, callback: func() {
+		ok, err := restoreMinimized()
+		if err != nil {
+			fmt.Printf("warn: restore minimized: %v\n", err)
+		}
+		acted = acted || ok
+	}})
+	hks = append(hks, HotKey{id: 202, name: "toggleMonitorDesktop", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32.VK_HOME, callback: func() {
+		ok, err := toggleMonitorDesktop()
+		if err != nil {
+			fmt.Printf("warn: monitor desktop: %v\n", err)
+		}
+		acted = acted || ok
+	}})
+	hks = append(hks, HotKey{id: 203, name: "cycleGap", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32.VK_OEM_PLUS, callback: func() {
+		ok, err := cycleGap()
+		if err != nil {
+			fmt.Printf("warn: cycle gap: %v\n", err)
+		}
+		acted = acted || ok
+	}})
+	hks = append(hks, HotKey{id: 207, name: "focusMode", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_F, callback: toggleFocusMode})
+	hks = append(hks, HotKey{id: 208, name: "promoteToPrimary", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32ex.VK_N_P, callback: onForeground("promote to primary", promoteToPrimary)})
+	hks = append(hks, HotKey{id: 58, name: "presentationMode", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_P, callback: onForeground("presentation mode", togglePresentationMode)})
+	hks = append(hks,
+		HotKey{id: 80, name: "flipHorizontal", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_X, callback: onForeground("flip", func(hwnd w32.HWND) (bool, error) { return flip(hwnd, leftHalf, rightHalf) })},
+		HotKey{id: 81, name: "flipVertical", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_Y, callback: onForeground("flip", func(hwnd w32.HWND) (bool, error) { return flip(hwnd, topHalf, bottomHalf) })},
+	)
+	hks = append(hks,
+		HotKey{id: 91, name: "moveToLeftEdge", mod: MOD_ALT | MOD_WIN | MOD_CONTROL, vk: w32.VK_LEFT, callback: onForeground("move to edge", func(hwnd w32.HWND) (bool, error) { return moveKeepingSize(hwnd, toLeftEdge) })},
+		HotKey{id: 92, name: "moveToRightEdge", mod: MOD_ALT | MOD_WIN | MOD_CONTROL, vk: w32.VK_RIGHT, callback: onForeground("move to edge", func(hwnd w32.HWND) (bool, error) { return moveKeepingSize(hwnd, toRightEdge) })},
+		HotKey{id: 93, name: "moveToTopEdge", mod: MOD_ALT | MOD_WIN | MOD_CONTROL, vk: w32.VK_UP, callback: onForeground("move to edge", func(hwnd w32.HWND) (bool, error) { return moveKeepingSize(hwnd, toTopEdge) })},
+		HotKey{id: 94, name: "moveToBottomEdge", mod: MOD_ALT | MOD_WIN | MOD_CONTROL, vk: w32.VK_DOWN, callback: onForeground("move to edge", func(hwnd w32.HWND) (bool, error) { return moveKeepingSize(hwnd, toBottomEdge) })},
+	)
+	hks = append(hks, HotKey{id: 99, name: "matchWindow", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_M, callback: onForeground("match window", matchWindow)})
+	hks = append(hks, HotKey{id: 98, name: "toggleLock", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_K, callback: onForeground("lock", toggleLock)})
+	hks = append(hks, HotKey{id: 206, name: "resizeMode", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_S, callback: onForeground("resize mode", toggleResizeMode)})
+	hks = append(hks, HotKey{id: 205, name: "resnap", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_T, callback: onForeground("resnap", resnap)})
+	hks = append(hks, HotKey{id: 209, name: "applyLayout", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_J, callback: onForeground("layout", applyLayout)})
+	hks = append(hks, HotKey{id: 96, name: "toggleZones", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_B, callback: onForeground("toggle zones", toggleZones)})
+	hks = append(hks, HotKey{id: 95, name: "fitAspectRatio", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_O, callback: onForeground("fit aspect ratio", fitAspectRatio)})
+	hks = append(hks, HotKey{id: 201, name: "undoMonitorMove", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32.VK_INSERT, callback: func() {
+		ok, err := undoMonitorMove()
+		if err != nil {
+			fmt.Printf("warn: undo monitor move: %v\n", err)
+		}
+		acted = acted || ok
+	}})
+	hks = append(hks, HotKey{id: 88, name: "nextMonitorMaximized", mod: MOD_ALT | MOD_WIN | MOD_SHIFT, vk: w32.VK_DELETE, callback: onForeground("next monitor maximized", moveToNextMonitorMaximized)})
+	hks = append(hks,
+		HotKey{id: 84, name: "slideLeft", mod: MOD_ALT | MOD_WIN | MOD_SHIFT, vk: w32.VK_LEFT, callback: onForeground("slide", func(hwnd w32.HWND) (bool, error) { return slideToMonitor(hwnd, -1) })},
+		HotKey{id: 85, name: "slideRight", mod: MOD_ALT | MOD_WIN | MOD_SHIFT, vk: w32.VK_RIGHT, callback: onForeground("slide", func(hwnd w32.HWND) (bool, error) { return slideToMonitor(hwnd, 1) })},
+	)
+	hks = append(hks,
+		HotKey{id: 86, name: "dumpLayout", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_L, callback: func() {
+			if err := dumpLayout(); err != nil {
+				fmt.Printf("warn: dump layout: %v\n", err)
+			}
+		}},
+		HotKey{id: 87, name: "applyAppZones", mod: MOD_ALT | MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: w32ex.VK_N_L, callback: func() {
+			ok, err := applyAppZones()
+			if err != nil {
+				fmt.Printf("warn: app zones: %v\n", err)
+			}
+			acted = acted || ok
+		}},
+	)
+	if config.RememberMaximized {
+		hks = append(hks, HotKey{id: 83, name: "restorePlacement", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_R, callback: onForeground("restore placement", restorePlacement)})
+	}
+	if config.FollowApp != (AppMatcher{}) {
+		hks = append(hks, HotKey{id: 56, name: "followApp", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32ex.VK_N_A, callback: onForeground("follow app", moveToAppMonitor)})
+	}
+	if config.BorderlessToggle {
+		hks = append(hks, HotKey{id: 54, name: "borderless", mod: MOD_ALT | MOD_WIN | MOD_NOREPEAT, vk: w32.VK_RETURN, callback: onForeground("borderless", toggleBorderless)})
+	}
+	hks = append(hks, HotKey{id: 60, name: "cycleSlots", mod: MOD_WIN | MOD_NOREPEAT, vk: '0', callback: onForeground("cycle slots", cycleSlots)})
+	for i := 1; i <= numSlots; i++ {
+		n := i
+		hks = append(hks,
+			HotKey{id: 60 + n, name: fmt.Sprintf("recallSlot%d", n), mod: MOD_WIN | MOD_NOREPEAT, vk: '0' + n, callback: onForeground("recall slot", func(hwnd w32.HWND) (bool, error) { return recallSlot(hwnd, n) })},
+			HotKey{id: 70 + n, name: fmt.Sprintf("saveSlot%d", n), mod: MOD_WIN | MOD_SHIFT | MOD_NOREPEAT, vk: '0' + n, callback: onForeground("save slot", func(hwnd w32.HWND) (bool, error) { return saveSlot(hwnd, n) })},
+		)
 	}
 
-	var failedHotKeys []HotKey
+	hks = remapModifier(hks, config.RemapModifier)
+	presetHotKeys, rotatePresets := tilingPresetHotKeys() // used as configured, not remapped
+	hks = append(hks, presetHotKeys...)
+	for name, f := range rotatePresets {
+		reverseCycles[name] = f
+	}
+	if config.Profile != "" {
+		if *verbose {
+			fmt.Printf("hotkey profile: %s\n", config.Profile)
+		}
+		hks = bindHotKeys(hks, hotKeyProfiles[config.Profile])
+	}
+	hks = bindHotKeys(hks, config.HotKeys)
+	hks = append(hks, reverseHotKeys(hks, reverseCycles)...)
+	hks = withFeedback(hks, config.ActionFeedback)
+	hks = withCooldowns(hks, config.Cooldowns)
+	hks, conflicts := dropConflictingHotKeys(hks)
+
+	var failedHotKeys, winHotKeys []HotKey
 	for _, hk := range hks {
-		if !RegisterHotKey(hk) {
+		if config.WinKeyHook && hk.mod&MOD_WIN != 0 {
+			winHotKeys = append(winHotKeys, hk)
+		} else if !RegisterHotKey(hk) {
 			failedHotKeys = append(failedHotKeys, hk)
 		}
 	}
+	if len(winHotKeys) > 0 {
+		if err := installKeyboardHook(winHotKeys); err != nil {
+			fmt.Printf("warn: keyboard hook: %v, falling back to RegisterHotKey\n", err)
+			for _, hk := range winHotKeys {
+				if !RegisterHotKey(hk) {
+					failedHotKeys = append(failedHotKeys, hk)
+				}
+			}
+		}
+	}
+	if err := installMouseHook(hks, config.MouseBindings); err != nil {
+		fmt.Printf("warn: mouse hook: %v\n", err)
+	}
+	fmt.Printf("RectangleWin %s started, %d hotkeys registered\n", version, len(hks)-len(failedHotKeys))
 	if len(failedHotKeys) > 0 {
 		msg := "The following hotkey(s) are in use by another process:\n\n"
 		for _, hk := range failedHotKeys {
@@ -116,8 +288,26 @@ func main() {
 		msg += "\nTo use these hotkeys in RectangleWin, close the other process using the key combination(s)."
 		showMessageBox(msg)
 	}
+	if len(conflicts) > 0 {
+		msg := "The following hotkey(s) are configured for more than one action, and only work for the first one:\n\n"
+		for _, c := range conflicts {
+			msg += "  - " + c + "\n"
+		}
+		msg += "\nChange the hotkeys or remapModifier configuration to use different key combinations."
+		showMessageBox(msg)
+	}
 
-	exitCh := make(chan os.Signal)
+	runStartupActions(hks)
+	bindTrayClicks(hks, config.TrayClicks)
+	if config.NewWindowsUnderCursor {
+		rememberExistingWindows()
+	}
+	installWinEventHooks()
+	if err := createListenerWindow(); err != nil {
+		fmt.Printf("warn: listener window: %v\n", err)
+	}
+
+	exitCh := make(chan os.Signal, 1)
 	signal.Notify(exitCh, os.Interrupt)
 	go func() {
 		<-exitCh
@@ -135,9 +325,29 @@ func main() {
 	}
 }
 
+// onForeground returns a hotkey callback that runs f on the foreground window.
+func onForeground(name string, f func(hwnd w32.HWND) (bool, error)) func() {
+	return func() {
+		hwnd := w32.GetForegroundWindow()
+		if hwnd == 0 {
+			panic("foreground window is NULL")
+		}
+		ok, err := f(hwnd)
+		if err != nil {
+			fmt.Printf("warn: %s: %v\n", name, err)
+		}
+		acted = acted || ok
+	}
+}
+
 func showMessageBox(text string) {
 	w32.MessageBox(w32.GetActiveWindow(), text, "RectangleWin", w32.MB_ICONWARNING|w32.MB_OK)
 }
+
+// confirm asks a yes/no question and reports whether the answer was yes.
+func confirm(text string) bool {
+	return w32.MessageBox(0, text, "RectangleWin", w32.MB_ICONQUESTION|w32.MB_YESNO) == w32.IDYES
+}
 func modNeg(v, m int) int {
 	return (v%m + m) % m
 }
@@ -146,27 +356,75 @@ func moveToNextMonitor(hwnd w32.HWND) (bool, error) {
 		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
 		return false, nil
 	}
-	rect := w32.GetWindowRect(hwnd)
-	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
-	hdc := w32.GetDC(hwnd)
-	displayDPI := w32.GetDeviceCaps(hdc, w32.LOGPIXELSY)
+	rememberMonitorMove(hwnd)
+	return moveToMonitor(hwnd, nextMonitor(w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST), hwnd))
+}
 
-	var monitors []w32.HMONITOR
+// nextMonitor returns the monitor windows on mon are moved to next.
+func nextMonitor(mon w32.HMONITOR, hwnd w32.HWND) w32.HMONITOR {
+	monitors := rotationMonitors(mon, hwnd)
 	monitorIndex := 0
-	curMonitorIndex := 0
-
-	EnumMonitors(func(d w32.HMONITOR) bool {
-		monitors = append(monitors, d)
+	for i, d := range monitors {
 		if d == mon {
-			monitorIndex = curMonitorIndex
+			monitorIndex = i
 		}
-		curMonitorIndex += 1
-		return true
-	})
+	}
 
 	// move to monitor_index + 1
-	mon = monitors[modNeg(monitorIndex-1, len(monitors))]
+	return monitors[modNeg(monitorIndex-1, len(monitors))]
+}
+
+// moveToNextMonitorMaximized moves the window to the next monitor and
+// maximizes it there.
+func moveToNextMonitorMaximized(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	mon := nextMonitor(w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST), hwnd)
+	rememberMonitorMove(hwnd)
+	if w32ex.IsZoomed(hwnd) && !w32.ShowWindow(hwnd, w32.SW_RESTORE) {
+		return false, fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
+	}
+	if _, err := moveToMonitor(hwnd, mon); err != nil {
+		return false, err
+	}
+	if !w32.ShowWindow(hwnd, w32.SW_MAXIMIZE) {
+		return false, fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
+	}
+	fmt.Printf("> maximized on monitor 0x%x (%s)\n", mon, monitorDeviceName(mon))
+	return true, nil
+}
 
+// moveToAppMonitor moves the window to the monitor showing the topmost window
+// of the app configured with followApp.
+func moveToAppMonitor(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	for _, w := range zonableWindows(orderZ, 0) {
+		if w.hwnd == hwnd || !config.FollowApp.matches(w.hwnd) {
+			continue
+		}
+		mon := w32.MonitorFromWindow(w.hwnd, w32.MONITOR_DEFAULTTONEAREST)
+		fmt.Printf("> following app (%s): window 0x%x %q on monitor 0x%x\n", config.FollowApp, w.hwnd, w32.GetWindowText(w.hwnd), mon)
+		if mon == w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST) {
+			fmt.Println("already on the monitor of the app")
+			return false, nil
+		}
+		rememberMonitorMove(hwnd)
+		return moveToMonitor(hwnd, mon)
+	}
+	fmt.Printf("no window of app (%s) is open\n", config.FollowApp)
+	return false, nil
+}
+
+// moveToMonitor centers the window on the work area of the given monitor.
+func moveToMonitor(hwnd w32.HWND, mon w32.HMONITOR) (bool, error) {
+	rect := w32.GetWindowRect(hwnd)
+	hdc := w32.GetDC(hwnd)
+	displayDPI := w32.GetDeviceCaps(hdc, w32.LOGPIXELSY)
 	if !w32.ReleaseDC(hwnd, hdc) {
 		return false, fmt.Errorf("failed to ReleaseDC:%d", w32.GetLastError())
 	}
@@ -174,50 +432,60 @@ func moveToNextMonitor(hwnd w32.HWND) (bool, error) {
 	if !w32.GetMonitorInfo(mon, &monInfo) {
 		return false, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
 	}
+	monInfo.RcWork = taskbarWorkArea(mon, monInfo)
 
 	ok, frame := w32.DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS(hwnd)
 	if !ok {
 		return false, fmt.Errorf("failed to DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS:%d", w32.GetLastError())
 	}
-	windowDPI := w32ex.GetDpiForWindow(hwnd)
-	resizedFrame := resizeForDpi(frame, int32(windowDPI), int32(displayDPI))
+	windowDPI := frameDpi(hwnd, int32(displayDPI))
+	resizedFrame := resizeForDpi(frame, windowDPI, int32(displayDPI))
 
 	fmt.Printf("> window: 0x%x %#v (w:%d,h:%d) mon=0x%X(@ display DPI:%d)\n", hwnd, rect, rect.Width(), rect.Height(), mon, displayDPI)
 	fmt.Printf("> DWM frame:        %#v (W:%d,H:%d) @ window DPI=%v\n", frame, frame.Width(), frame.Height(), windowDPI)
 	fmt.Printf("> DPI-less frame:   %#v (W:%d,H:%d)\n", resizedFrame, resizedFrame.Width(), resizedFrame.Height())
 
-	// calculate how many extra pixels go to win10 invisible borders
-	lExtra := resizedFrame.Left - rect.Left
-	rExtra := -resizedFrame.Right + rect.Right
-	tExtra := resizedFrame.Top - rect.Top
-	bExtra := -resizedFrame.Bottom + rect.Bottom
-
-	newPos := center(monInfo.RcWork, resizedFrame)
-
-	// adjust offsets based on invisible borders
-	newPos.Left -= lExtra
-	newPos.Top -= tExtra
-	newPos.Right += rExtra
-	newPos.Bottom += bExtra
+	borders := invisibleBorders(*rect, resizedFrame)
+	zone := center(monInfo.RcWork, resizedFrame)
+	newPos := withBorders(zone, borders)
 
-	lastResized = hwnd
+	markResized(hwnd)
 	if sameRect(rect, &newPos) {
 		fmt.Println("no resize")
 		return false, nil
 	}
 
 	fmt.Printf("> resizing to: %#v (W:%d,H:%d)\n", newPos, newPos.Width(), newPos.Height())
-	if !w32.ShowWindow(hwnd, w32.SW_SHOWNORMAL) { // normalize window first if it's set to SW_SHOWMAXIMIZE (and therefore stays maximized)
+	if m, ok := matchApp(config.SkipNormalize, hwnd); ok {
+		fmt.Printf("> skipping normalize for app (%s)\n", m)
+	} else if !w32.ShowWindow(hwnd, w32.SW_SHOWNORMAL) { // normalize window first if it's set to SW_SHOWMAXIMIZE (and therefore stays maximized)
 		return false, fmt.Errorf("failed to normalize window ShowWindow:%d", w32.GetLastError())
 	}
-	if !w32.SetWindowPos(hwnd, 0, int(newPos.Left), int(newPos.Top), int(newPos.Width()), int(newPos.Height()), w32.SWP_NOZORDER|w32.SWP_NOACTIVATE) {
-		return false, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+	if err := setWindowPos(hwnd, newPos); err != nil {
+		return false, err
 	}
 	rect = w32.GetWindowRect(hwnd)
 	fmt.Printf("> post-resize: %#v(W:%d,H:%d)\n", rect, rect.Width(), rect.Height())
+	if config.WarpCursor {
+		warpCursor(*rect)
+	}
+	flashZone(zone)
 	return true, nil
 }
 
+// warpCursor moves the mouse cursor to the center of the rect, unless it was
+// anchored within the window already (see config.AnchorCursor).
+func warpCursor(r w32.RECT) {
+	if x, y, ok := w32.GetCursorPos(); ok && config.AnchorCursor && inRect(x, y, r) {
+		return
+	}
+	x, y := int(r.Left+r.Width()/2), int(r.Top+r.Height()/2)
+	fmt.Printf("> warping cursor to (%d,%d)\n", x, y)
+	if !w32.SetCursorPos(x, y) {
+		fmt.Printf("warn: failed to SetCursorPos:%d\n", w32.GetLastError())
+	}
+}
+
 type resizeFunc func(disp, cur w32.RECT) w32.RECT
 
 func center(disp, cur w32.RECT) w32.RECT {
@@ -230,7 +498,19 @@ func center(disp, cur w32.RECT) w32.RECT {
 		Bottom: disp.Top + h + cur.Height()}
 }
 
+// resize moves the window to the zone returned by f, with the gap around it.
 func resize(hwnd w32.HWND, f resizeFunc) (bool, error) {
+	return resizeWindow(hwnd, f, true)
+}
+
+// moveKeepingSize moves the window to the rect returned by f as is, for
+// resizeFuncs that keep the size of the window (edge moves, centering), which
+// the gap would shrink on each move.
+func moveKeepingSize(hwnd w32.HWND, f resizeFunc) (bool, error) {
+	return resizeWindow(hwnd, f, false)
+}
+
+func resizeWindow(hwnd w32.HWND, f resizeFunc, gapped bool) (bool, error) {
 	if !isZonableWindow(hwnd) {
 		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
 		return false, nil
@@ -246,68 +526,197 @@ func resize(hwnd w32.HWND, f resizeFunc) (bool, error) {
 	if !w32.GetMonitorInfo(mon, &monInfo) {
 		return false, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
 	}
+	monInfo.RcWork = taskbarWorkArea(mon, monInfo)
 
 	ok, frame := w32.DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS(hwnd)
 	if !ok {
 		return false, fmt.Errorf("failed to DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS:%d", w32.GetLastError())
 	}
-	windowDPI := w32ex.GetDpiForWindow(hwnd)
-	resizedFrame := resizeForDpi(frame, int32(windowDPI), int32(displayDPI))
+	windowDPI := frameDpi(hwnd, int32(displayDPI))
+	resizedFrame := resizeForDpi(frame, windowDPI, int32(displayDPI))
 
 	fmt.Printf("> window: 0x%x %#v (w:%d,h:%d) mon=0x%X(@ display DPI:%d)\n", hwnd, rect, rect.Width(), rect.Height(), mon, displayDPI)
 	fmt.Printf("> DWM frame:        %#v (W:%d,H:%d) @ window DPI=%v\n", frame, frame.Width(), frame.Height(), windowDPI)
 	fmt.Printf("> DPI-less frame:   %#v (W:%d,H:%d)\n", resizedFrame, resizedFrame.Width(), resizedFrame.Height())
 
-	// calculate how many extra pixels go to win10 invisible borders
-	lExtra := resizedFrame.Left - rect.Left
-	rExtra := -resizedFrame.Right + rect.Right
-	tExtra := resizedFrame.Top - rect.Top
-	bExtra := -resizedFrame.Bottom + rect.Bottom
-
-	newPos := f(monInfo.RcWork, resizedFrame)
+	borders := invisibleBorders(*rect, resizedFrame)
 
-	// adjust offsets based on invisible borders
-	newPos.Left -= lExtra
-	newPos.Top -= tExtra
-	newPos.Right += rExtra
-	newPos.Bottom += bExtra
+	var cell, newPos, edges w32.RECT // edges are checked against the work area by flushTaskbarEdge
+	if gapped {
+		cell = f(monInfo.RcWork, withoutGap(resizedFrame, monInfo.RcWork, config.Gap))
+		newPos, edges = withGap(cell, monInfo.RcWork, config.Gap), cell
+	} else {
+		newPos = f(monInfo.RcWork, resizedFrame)
+		cell, edges = withoutGap(newPos, monInfo.RcWork, config.Gap), newPos
+	}
+	zone := newPos
+	newPos = withBorders(newPos, borders)
+	if config.FlushTaskbarEdge {
+		flushTaskbarEdge(mon, monInfo.RcWork, edges, borders, &newPos)
+	}
 
-	lastResized = hwnd
+	markResized(hwnd)
 	if sameRect(rect, &newPos) {
 		fmt.Println("no resize")
 		return false, nil
 	}
 
 	fmt.Printf("> resizing to: %#v (W:%d,H:%d)\n", newPos, newPos.Width(), newPos.Height())
-	if !w32.ShowWindow(hwnd, w32.SW_SHOWNORMAL) { // normalize window first if it's set to SW_SHOWMAXIMIZE (and therefore stays maximized)
+	rememberMaximized(hwnd)
+	anchor, anchored := anchorCursor(frame)
+	if m, ok := matchApp(config.SkipNormalize, hwnd); ok {
+		fmt.Printf("> skipping normalize for app (%s)\n", m)
+	} else if !w32.ShowWindow(hwnd, w32.SW_SHOWNORMAL) { // normalize window first if it's set to SW_SHOWMAXIMIZE (and therefore stays maximized)
 		return false, fmt.Errorf("failed to normalize window ShowWindow:%d", w32.GetLastError())
 	}
-	if !w32.SetWindowPos(hwnd, 0, int(newPos.Left), int(newPos.Top), int(newPos.Width()), int(newPos.Height()), w32.SWP_NOZORDER|w32.SWP_NOACTIVATE) {
-		return false, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+	if err := setWindowPos(hwnd, newPos); err != nil {
+		return false, err
 	}
+	settleWindow(hwnd, newPos)
+	from := *rect
 	rect = w32.GetWindowRect(hwnd)
 	fmt.Printf("> post-resize: %#v(W:%d,H:%d)\n", rect, rect.Width(), rect.Height())
+	moveOwnedWindows(hwnd, from, *rect)
+	if anchored {
+		anchor.warp(hwnd)
+	}
+	rememberZone(hwnd, mon, monInfo.RcWork, cell)
+	flashZone(zone)
 	return true, nil
 }
 
+// transientSetWindowPosErrors are the SetWindowPos error codes that apps
+// return while busy with their own layout, after which retrying can succeed.
+var transientSetWindowPosErrors = map[uint32]bool{
+	0:    true, // some apps reject the move without setting an error
+	5:    true, // ERROR_ACCESS_DENIED
+	1400: true, // ERROR_INVALID_WINDOW_HANDLE, while the window is being recreated
+	1460: true, // ERROR_TIMEOUT
+}
+
+// setWindowPos moves the window to the given rect without changing its
+// Z-order or activating it. Transient failures are retried up to
+// config.SetWindowPosRetries times with an exponential backoff, checking
+// whether the window has reached the rect anyway in between.
+func setWindowPos(hwnd w32.HWND, r w32.RECT) error {
+	backoff := setWindowPosBackoff
+	for i := 0; ; i++ {
+		if w32.SetWindowPos(hwnd, 0, int(r.Left), int(r.Top), int(r.Width()), int(r.Height()), w32.SWP_NOZORDER|w32.SWP_NOACTIVATE) {
+			acted = true
+			return nil
+		}
+		code := w32.GetLastError()
+		if i >= config.SetWindowPosRetries || !transientSetWindowPosErrors[code] {
+			return fmt.Errorf("failed to SetWindowPos:%d", code)
+		}
+		fmt.Printf("> SetWindowPos failed:%d, retrying in %v (%d/%d)\n", code, backoff, i+1, config.SetWindowPosRetries)
+		time.Sleep(backoff)
+		backoff *= 2
+		if sameRect(w32.GetWindowRect(hwnd), &r) {
+			fmt.Println("> window reached the position after all")
+			acted = true
+			return nil
+		}
+	}
+}
+
+// visibleFrame returns the visible (DWM) frame of the window scaled to the
+// display DPI, which is the coordinate space resizeFuncs operate in.
+func visibleFrame(hwnd w32.HWND) (w32.RECT, error) {
+	hdc := w32.GetDC(hwnd)
+	displayDPI := w32.GetDeviceCaps(hdc, w32.LOGPIXELSY)
+	if !w32.ReleaseDC(hwnd, hdc) {
+		return w32.RECT{}, fmt.Errorf("failed to ReleaseDC:%d", w32.GetLastError())
+	}
+	ok, frame := w32.DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS(hwnd)
+	if !ok {
+		return w32.RECT{}, fmt.Errorf("failed to DwmGetWindowAttributeEXTENDED_FRAME_BOUNDS:%d", w32.GetLastError())
+	}
+	return resizeForDpi(frame, frameDpi(hwnd, int32(displayDPI)), int32(displayDPI)), nil
+}
+
+// invisibleBorders returns how many pixels of the window rect on each edge go
+// to the invisible (win10) borders around the visible frame.
+func invisibleBorders(rect, frame w32.RECT) w32.RECT {
+	return w32.RECT{
+		Left:   frame.Left - rect.Left,
+		Top:    frame.Top - rect.Top,
+		Right:  rect.Right - frame.Right,
+		Bottom: rect.Bottom - frame.Bottom,
+	}
+}
+
+// withBorders returns the window rect for a visible frame, adding the
+// invisible borders back.
+func withBorders(frame, borders w32.RECT) w32.RECT {
+	return w32.RECT{
+		Left:   frame.Left - borders.Left,
+		Top:    frame.Top - borders.Top,
+		Right:  frame.Right + borders.Right,
+		Bottom: frame.Bottom + borders.Bottom,
+	}
+}
+
+// frameDpi returns the DPI the DWM frame of the window is scaled for. Windows
+// of DPI unaware apps report 96 DPI while their DWM frame is in the same
+// physical coordinates as the display, so unless compensating for that is
+// disabled, the display DPI is used for them and their frame isn't scaled.
+// Their window rect needs no conversion back either, as SetWindowPos takes
+// the same coordinates for all windows.
+func frameDpi(hwnd w32.HWND, displayDPI int32) int32 {
+	windowDPI := w32ex.GetDpiForWindow(hwnd)
+	if config.DpiVirtualizationCompensation && windowDPI != displayDPI && isDpiUnaware(hwnd) {
+		fmt.Printf("> compensating DPI virtualization of window 0x%x (window DPI=%d, display DPI=%d)\n", hwnd, windowDPI, displayDPI)
+		return displayDPI
+	}
+	return windowDPI
+}
+
 func maximize() error {
 	hwnd := w32.GetForegroundWindow()
 	if !isZonableWindow(hwnd) {
 		return errors.New("foreground window is not zonable")
 	}
+	return maximizeWindow(hwnd)
+}
+
+// maximizeWindow maximizes the window as configured by maximizeMode.
+func maximizeWindow(hwnd w32.HWND) error {
+	if config.MaximizeMode == maximizeFill {
+		_, err := resize(hwnd, fullWorkArea)
+		return err
+	}
 	if !w32.ShowWindow(hwnd, w32.SW_MAXIMIZE) {
 		return fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
 	}
+	acted = true
 	return nil
 }
 
+// resizeForDpi scales src from one DPI to another. Each edge is rounded to the
+// nearest pixel (symmetrically for negative coordinates), so that at
+// fractional scales (125%, 150%, 175%) the result is off by at most half a
+// pixel and rects sharing an edge still share it after scaling.
 func resizeForDpi(src w32.RECT, from, to int32) w32.RECT {
+	if from == to || from == 0 || to == 0 {
+		return src
+	}
 	return w32.RECT{
-		Left:   src.Left * to / from,
-		Right:  src.Right * to / from,
-		Top:    src.Top * to / from,
-		Bottom: src.Bottom * to / from,
+		Left:   mulDivRound(src.Left, to, from),
+		Right:  mulDivRound(src.Right, to, from),
+		Top:    mulDivRound(src.Top, to, from),
+		Bottom: mulDivRound(src.Bottom, to, from),
+	}
+}
+
+// mulDivRound returns v*mul/div rounded to the nearest integer, with halves
+// rounded away from zero.
+func mulDivRound(v, mul, div int32) int32 {
+	n := int64(v) * int64(mul)
+	if n < 0 {
+		return int32((n - int64(div)/2) / int64(div))
 	}
+	return int32((n + int64(div)/2) / int64(div))
 }
 
 func sameRect(a, b *w32.RECT) bool {
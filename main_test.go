@@ -0,0 +1,88 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// fractionalDPIs are the DPIs of the 125%, 150% and 175% display scales.
+var fractionalDPIs = []int32{120, 144, 168}
+
+// complementaryZones are pairs of zones that tile the work area side by side.
+var complementaryZones = []struct {
+	name        string
+	left, right resizeFunc
+}{
+	{"halves", leftHalf, rightHalf},
+	{"leftTwoThirds+rightOneThirds", defaultSplit.leftTwoThirds, defaultSplit.rightOneThirds},
+	{"leftOneThirds+rightTwoThirds", defaultSplit.leftOneThirds, defaultSplit.rightTwoThirds},
+	{"leftTwoThirds+rightOneThirds/70%", split{70, 100}.leftTwoThirds, split{70, 100}.rightOneThirds},
+}
+
+func TestResizeForDpiComplementaryZonesTile(t *testing.T) {
+	for _, dpi := range fractionalDPIs {
+		for _, width := range []int32{1919, 1920, 2559, 2560} {
+			work := w32.RECT{Left: -width, Top: 0, Right: 0, Bottom: 1040} // a monitor left of the primary one
+			for _, z := range complementaryZones {
+				t.Run(fmt.Sprintf("%s/dpi=%d/width=%d", z.name, dpi, width), func(t *testing.T) {
+					// zones scaled from a window at 96 DPI to the display DPI
+					want := resizeForDpi(work, 96, dpi)
+					left := resizeForDpi(z.left(work, work), 96, dpi)
+					right := resizeForDpi(z.right(work, work), 96, dpi)
+					assertTiles(t, want, left, right)
+
+					// zones of the work area scaled to the display DPI, and back
+					left = resizeForDpi(z.left(want, want), dpi, 96)
+					right = resizeForDpi(z.right(want, want), dpi, 96)
+					assertTiles(t, resizeForDpi(want, dpi, 96), left, right)
+				})
+			}
+		}
+	}
+}
+
+// assertTiles checks that left and right meet without a gap or overlap and
+// together cover exactly the work area.
+func assertTiles(t *testing.T, work, left, right w32.RECT) {
+	t.Helper()
+	if left.Right != right.Left {
+		t.Errorf("left zone ends at %d, right zone starts at %d", left.Right, right.Left)
+	}
+	union := w32.RECT{Left: left.Left, Top: left.Top, Right: right.Right, Bottom: right.Bottom}
+	if union != work || left.Top != right.Top || left.Bottom != right.Bottom {
+		t.Errorf("zones %#v and %#v don't cover the work area %#v exactly", left, right, work)
+	}
+}
+
+func TestMulDivRound(t *testing.T) {
+	for _, tc := range []struct {
+		v, mul, div, want int32
+	}{
+		{v: 100, mul: 144, div: 96, want: 150},
+		{v: 1, mul: 120, div: 96, want: 1},      // 1.25
+		{v: 2, mul: 120, div: 96, want: 3},      // 2.5
+		{v: -2, mul: 120, div: 96, want: -3},    // -2.5
+		{v: 959, mul: 168, div: 96, want: 1678}, // 1678.25
+		{v: 0, mul: 168, div: 96, want: 0},
+	} {
+		if got := mulDivRound(tc.v, tc.mul, tc.div); got != tc.want {
+			t.Errorf("mulDivRound(%d, %d, %d) = %d, want %d", tc.v, tc.mul, tc.div, got, tc.want)
+		}
+	}
+}
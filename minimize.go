@@ -0,0 +1,67 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// minimizedWindows are the windows minimized since RectangleWin started and
+// not restored since, most recent first.
+var minimizedWindows []w32.HWND
+
+func onMinimizeStart(hwnd w32.HWND) {
+	if !isZonableWindow(hwnd) {
+		return
+	}
+	out := []w32.HWND{hwnd}
+	for _, h := range minimizedWindows {
+		if h != hwnd && w32.IsWindow(h) && len(out) < maxRecentWindows {
+			out = append(out, h)
+		}
+	}
+	minimizedWindows = out
+}
+
+func onMinimizeEnd(hwnd w32.HWND) {
+	for i, h := range minimizedWindows {
+		if h == hwnd {
+			minimizedWindows = append(minimizedWindows[:i], minimizedWindows[i+1:]...)
+			return
+		}
+	}
+}
+
+// restoreMinimized restores and focuses the most recently minimized window
+// that is still minimized.
+func restoreMinimized() (bool, error) {
+	for len(minimizedWindows) > 0 {
+		hwnd := minimizedWindows[0]
+		minimizedWindows = minimizedWindows[1:]
+		if !w32.IsWindow(hwnd) || !isZonableWindow(hwnd) || !w32ex.IsIconic(hwnd) {
+			continue // closed or restored without us noticing
+		}
+		fmt.Printf("> restoring minimized window 0x%x %q\n", hwnd, w32.GetWindowText(hwnd))
+		w32.ShowWindow(hwnd, w32.SW_RESTORE)
+		setForeground(hwnd)
+		return true, nil
+	}
+	fmt.Println("no minimized window to restore")
+	return false, nil
+}
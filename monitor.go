@@ -20,6 +20,8 @@ import (
 
 	"github.com/gonutz/w32/v2"
 	"golang.org/x/sys/windows"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
 )
 
 func EnumMonitors(f func(d w32.HMONITOR) bool) bool {
@@ -32,6 +34,67 @@ func EnumMonitors(f func(d w32.HMONITOR) bool) bool {
 	return w32.EnumDisplayMonitors(0, nil, callback, 0)
 }
 
+// workArea returns the monitor the window is on and its work area.
+func workArea(hwnd w32.HWND) (w32.HMONITOR, w32.RECT, error) {
+	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
+	var monInfo w32.MONITORINFO
+	if !w32.GetMonitorInfo(mon, &monInfo) {
+		return 0, w32.RECT{}, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
+	}
+	return mon, taskbarWorkArea(mon, monInfo), nil
+}
+
+// monitorDeviceName returns the device name of the monitor (e.g. `\\.\DISPLAY1`).
+func monitorDeviceName(d w32.HMONITOR) string {
+	v, ok := w32ex.GetMonitorInfoEx(d)
+	if !ok {
+		return ""
+	}
+	return windows.UTF16ToString(v.SzDevice[:])
+}
+
+// rotationMonitors returns the monitors the window can be cycled between, in
+// enumeration order. Monitors excluded in the config, or skipped for their
+// orientation, are left out except for cur, so that a window can still be
+// moved away from them.
+func rotationMonitors(cur w32.HMONITOR, hwnd w32.HWND) []w32.HMONITOR {
+	var out []w32.HMONITOR
+	EnumMonitors(func(d w32.HMONITOR) bool {
+		if d != cur && config.isExcludedMonitor(monitorDeviceName(d)) {
+			fmt.Printf("> skipping excluded monitor 0x%x (%s)\n", d, monitorDeviceName(d))
+			return true
+		}
+		if d != cur && skipForOrientation(d, hwnd) {
+			fmt.Printf("> skipping %s monitor 0x%x (%s)\n", config.SkipOrientation, d, monitorDeviceName(d))
+			return true
+		}
+		out = append(out, d)
+		return true
+	})
+	return out
+}
+
+// skipForOrientation reports whether the monitor is skipped for the window as
+// configured by skipOrientation.
+func skipForOrientation(d w32.HMONITOR, hwnd w32.HWND) bool {
+	if config.SkipOrientation == "" {
+		return false
+	}
+	var v w32.MONITORINFO
+	if !w32.GetMonitorInfo(d, &v) {
+		return false
+	}
+	portrait := v.RcMonitor.Height() > v.RcMonitor.Width()
+	if config.SkipOrientation == skipPortrait {
+		return portrait
+	}
+	rect := w32.GetWindowRect(hwnd)
+	if rect == nil {
+		return false
+	}
+	return portrait != (rect.Height() > rect.Width())
+}
+
 func printMonitors() {
 	i := 0
 	EnumMonitors(func(d w32.HMONITOR) bool {
@@ -39,11 +102,12 @@ func printMonitors() {
 		if !w32.GetMonitorInfo(d, &v) {
 			return false
 		}
-		fmt.Printf("> monitor#%d: 0x%x\n", i, d)
+		fmt.Printf("> monitor#%d: 0x%x (%s)\n", i, d, monitorDeviceName(d))
 		i++
 		fmt.Printf("       rcwork:%#v (w=%v,h=%v)\n", v.RcWork, v.RcWork.Width(), v.RcWork.Height())
 		fmt.Printf("    rcmonitor:%#v (w=%v,h=%v)\n", v.RcMonitor, v.RcMonitor.Width(), v.RcWork.Height())
 		fmt.Printf("      primary:%#v\n", v.DwFlags&w32.MONITORINFOF_PRIMARY > 0)
+		fmt.Printf("     excluded:%#v\n", config.isExcludedMonitor(monitorDeviceName(d)))
 
 		ok, n := w32.GetNumberOfPhysicalMonitorsFromHMONITOR(d)
 		if !ok {
@@ -63,3 +127,85 @@ func printMonitors() {
 		return true
 	})
 }
+
+// slideToMonitor moves the window by exactly the width of its monitor to the
+// left (dir=-1) or right (dir=1), keeping its position within the monitor, if
+// the adjacent monitor is next to it in the same row and has the same height
+// and DPI. Otherwise, the window is centered on the nearest monitor in that
+// direction, as Windows rescales windows moved to a monitor of another DPI.
+func slideToMonitor(hwnd w32.HWND, dir int32) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
+	var cur w32.MONITORINFO
+	if !w32.GetMonitorInfo(mon, &cur) {
+		return false, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
+	}
+	var next w32.HMONITOR
+	var nextInfo w32.MONITORINFO
+	var nextDist int32
+	for _, d := range rotationMonitors(mon, hwnd) {
+		var v w32.MONITORINFO
+		if d == mon || !w32.GetMonitorInfo(d, &v) {
+			continue
+		}
+		dist := (v.RcMonitor.Left + v.RcMonitor.Width()/2 - cur.RcMonitor.Left - cur.RcMonitor.Width()/2) * dir
+		if dist > 0 && (next == 0 || dist < nextDist) {
+			next, nextInfo, nextDist = d, v, dist
+		}
+	}
+	if next == 0 {
+		fmt.Println("no monitor in that direction")
+		return false, nil
+	}
+	rememberMonitorMove(hwnd)
+	a, b := cur.RcMonitor, nextInfo.RcMonitor
+	adjacent := (dir > 0 && b.Left == a.Right) || (dir < 0 && b.Right == a.Left)
+	inRow := adjacent && a.Top == b.Top && a.Height() == b.Height()
+	sameDpi := w32ex.GetDpiForMonitor(mon) == w32ex.GetDpiForMonitor(next)
+	if !sameDpi {
+		fmt.Printf("> monitor 0x%x has another DPI than 0x%x, moving to its center\n", next, mon)
+		return moveToMonitor(hwnd, next)
+	}
+	if !inRow && !config.KeepOnScreen {
+		fmt.Printf("> monitor 0x%x is not next to 0x%x in a row, moving to its center\n", next, mon)
+		return moveToMonitor(hwnd, next)
+	}
+	work := taskbarWorkArea(next, nextInfo)
+	dx := b.Left - a.Left
+	fmt.Printf("> sliding window by %dpx to monitor 0x%x\n", dx, next)
+	ok, err := moveKeepingSize(hwnd, func(_, cur w32.RECT) w32.RECT {
+		slid := w32.RECT{Left: cur.Left + dx, Top: cur.Top, Right: cur.Right + dx, Bottom: cur.Bottom}
+		out := clamp(slid, work)
+		if out.Top != slid.Top || out.Height() != slid.Height() {
+			fmt.Printf("> clamped window vertically into monitor 0x%x: top %d->%d, height %d->%d\n", next, slid.Top, out.Top, slid.Height(), out.Height())
+		}
+		return out
+	})
+	if ok && config.WarpCursor {
+		if rect := w32.GetWindowRect(hwnd); rect != nil {
+			warpCursor(*rect)
+		}
+	}
+	return ok, err
+}
+
+// onForegroundChanged snaps windows RectangleWin never placed to the default
+// zone of their monitor when they get focused, if one is configured.
+func onForegroundChanged(hwnd w32.HWND) {
+	if len(config.MonitorDefaultZones) == 0 || presentation != nil || placedWindows[hwnd] || !isZonableWindow(hwnd) {
+		return
+	}
+	device := monitorDeviceName(w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST))
+	zone, ok := config.MonitorDefaultZones[device]
+	if !ok {
+		return
+	}
+	fmt.Printf("> snapping unplaced window 0x%x %q to %s, the default zone of %s\n", hwnd, w32.GetWindowText(hwnd), zone, device)
+	if _, err := resize(hwnd, zoneFor(hwnd, zone)); err != nil {
+		fmt.Printf("warn: default zone: %v\n", err)
+	}
+	placedWindows[hwnd] = true // even if it failed, so that it's not retried on each focus
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+const llmhfInjected = 0x1 // MSLLHOOKSTRUCT.Flags: the event was injected
+
+// mouse buttons that actions can be bound to
+const (
+	mouseMiddle = iota
+	mouseX1
+	mouseX2
+)
+
+var mouseButtonsByName = map[string]int{
+	"middle":  mouseMiddle,
+	"x1":      mouseX1,
+	"back":    mouseX1,
+	"x2":      mouseX2,
+	"forward": mouseX2,
+}
+
+// MouseBinding is a mouse button, optionally combined with modifiers,
+// configured for an action.
+type MouseBinding struct {
+	Mods   []string `json:"mods"`   // see modKeysByName
+	Button string   `json:"button"` // see mouseButtonsByName
+}
+
+func (b MouseBinding) parse() (mod, button int, err error) {
+	if mod, err = parseModifiers(b.Mods); err != nil {
+		return 0, 0, err
+	}
+	button, ok := mouseButtonsByName[strings.ToLower(b.Button)]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown mouse button %q, valid buttons are: %s", b.Button, strings.Join(sortedKeys(mouseButtonsByName), " "))
+	}
+	return mod, button, nil
+}
+
+// mouseAction is an action bound to a mouse button.
+type mouseAction struct {
+	mod, button int
+	name        string
+	callback    func()
+}
+
+var (
+	mouseHook       w32.HHOOK
+	mouseActions    []mouseAction
+	mouseHeldButton = -1 // button of the action held down, whose release is swallowed
+)
+
+// installMouseHook binds the actions of the hotkeys to the configured mouse
+// buttons with a low-level mouse hook. Only the bound buttons are swallowed.
+func installMouseHook(hks []HotKey, bindings map[string]MouseBinding) error {
+	known := make(map[string]bool)
+	for _, hk := range hks {
+		known[hk.name] = true
+		b, ok := bindings[hk.name]
+		if !ok {
+			continue
+		}
+		mod, button, err := b.parse()
+		if err != nil {
+			// already checked by Config.validate
+			panic(err)
+		}
+		mouseActions = append(mouseActions, mouseAction{mod: mod, button: button, name: hk.name, callback: hk.callback})
+	}
+	for name := range bindings {
+		if !known[name] {
+			fmt.Printf("warn: mouseBindings: unknown or disabled action %q\n", name)
+		}
+	}
+	if len(mouseActions) == 0 {
+		return nil
+	}
+	mouseHook = w32.SetWindowsHookEx(w32.WH_MOUSE_LL, mouseHookProc, w32.GetModuleHandle(""), 0)
+	if mouseHook == 0 {
+		return fmt.Errorf("failed to SetWindowsHookEx:%d", w32.GetLastError())
+	}
+	return nil
+}
+
+func mouseHookProc(code int, wParam w32.WPARAM, lParam w32.LPARAM) w32.LRESULT {
+	if code < 0 {
+		return w32.CallNextHookEx(mouseHook, code, wParam, lParam)
+	}
+	ms := *(**w32ex.MSLLHOOKSTRUCT)(unsafe.Pointer(&lParam))
+	if ms.Flags&llmhfInjected != 0 {
+		return w32.CallNextHookEx(mouseHook, code, wParam, lParam)
+	}
+	button := -1
+	switch wParam {
+	case w32.WM_MBUTTONDOWN, w32.WM_MBUTTONUP:
+		button = mouseMiddle
+	case w32.WM_XBUTTONDOWN, w32.WM_XBUTTONUP:
+		if ms.MouseData>>16 == w32.XBUTTON1 {
+			button = mouseX1
+		} else {
+			button = mouseX2
+		}
+	}
+	switch wParam {
+	case w32.WM_MBUTTONDOWN, w32.WM_XBUTTONDOWN:
+		mod := pressedModifiers()
+		for _, a := range mouseActions {
+			if a.button != button || a.mod != mod {
+				continue
+			}
+			mouseHeldButton = button
+			fmt.Printf("trace: mouse action %s\n", a.name)
+			runOnMainThread(a.callback) // hook procs must return quickly
+			return 1
+		}
+	case w32.WM_MBUTTONUP, w32.WM_XBUTTONUP:
+		if mouseHeldButton == button {
+			mouseHeldButton = -1
+			return 1
+		}
+	}
+	return w32.CallNextHookEx(mouseHook, code, wParam, lParam)
+}
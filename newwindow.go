@@ -0,0 +1,93 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// newWindowDelay is how long after a new window is shown it is moved to the
+// monitor under the cursor, so that apps positioning their own windows are done.
+const newWindowDelay = 300 * time.Millisecond
+
+// shownWindows are the windows that were shown at least once, so that windows
+// shown again (e.g. restored from the tray) are not taken for new ones.
+var shownWindows = make(map[w32.HWND]bool)
+
+// rememberExistingWindows records the windows that exist on startup (or when
+// the window state is reset) as shown.
+func rememberExistingWindows() {
+	w32.EnumWindows(func(h w32.HWND) bool {
+		shownWindows[h] = true
+		return true
+	})
+}
+
+// onWindowShown moves new windows to the monitor under the cursor, once, if
+// configured. Windows the app moved by itself after showing them are left alone.
+func onWindowShown(hwnd w32.HWND) {
+	if !config.NewWindowsUnderCursor {
+		return
+	}
+	if shownWindows[hwnd] || !isZonableWindow(hwnd) {
+		return
+	}
+	for h := range shownWindows {
+		if !w32.IsWindow(h) {
+			delete(shownWindows, h)
+		}
+	}
+	shownWindows[hwnd] = true
+	rect := w32.GetWindowRect(hwnd)
+	if rect == nil {
+		return
+	}
+	shown := *rect
+	go func() {
+		time.Sleep(newWindowDelay)
+		runOnMainThread(func() {
+			if _, err := moveNewWindow(hwnd, shown); err != nil {
+				fmt.Printf("warn: new window: %v\n", err)
+			}
+		})
+	}()
+}
+
+// moveNewWindow moves the new window to the monitor under the cursor, at the
+// center, if it is on another one and still where it was when it was shown.
+func moveNewWindow(hwnd w32.HWND, shown w32.RECT) (bool, error) {
+	if !isZonableWindow(hwnd) || w32ex.IsIconic(hwnd) || w32ex.IsZoomed(hwnd) || presentation != nil {
+		return false, nil
+	}
+	if rect := w32.GetWindowRect(hwnd); rect == nil || *rect != shown {
+		fmt.Printf("> new window 0x%x %q moved by its app, leaving it\n", hwnd, w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	x, y, ok := w32.GetCursorPos()
+	if !ok {
+		return false, fmt.Errorf("failed to GetCursorPos:%d", w32.GetLastError())
+	}
+	mon := w32.MonitorFromPoint(x, y, w32.MONITOR_DEFAULTTONEAREST)
+	if mon == w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST) {
+		return false, nil
+	}
+	fmt.Printf("> moving new window 0x%x %q to the monitor under the cursor 0x%x\n", hwnd, w32.GetWindowText(hwnd), mon)
+	return moveToMonitor(hwnd, mon)
+}
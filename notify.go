@@ -0,0 +1,117 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gonutz/w32/v2"
+	"golang.org/x/sys/windows"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+const (
+	// systray library internals used to show balloons on its notification icon
+	systrayClassName   = "SystrayClass"
+	systrayIconID      = 100
+	systrayIconMessage = w32.WM_USER + 1 // notifies clicks on the icon
+)
+
+// muted reports whether notifications and feedback sounds are muted, and the
+// mode muting them.
+func muted() (string, bool) {
+	if presentation != nil && config.PresentationHideNotifications {
+		return "presentation mode", true
+	}
+	if isQuiet(time.Now()) {
+		return "quiet mode", true
+	}
+	return "", false
+}
+
+// notify shows a toast (balloon) notification from the tray icon.
+func notify(text string) {
+	fmt.Printf("notify: %s\n", text)
+	if why, ok := muted(); ok {
+		fmt.Printf("> notification hidden in %s\n", why)
+		return
+	}
+	hwnd := trayWindow()
+	if hwnd == 0 {
+		fmt.Println("warn: notify: tray icon window not found")
+		return
+	}
+	nid := w32ex.NOTIFYICONDATA{
+		HWnd:        hwnd,
+		UID:         systrayIconID,
+		UFlags:      w32ex.NIF_INFO,
+		DwInfoFlags: w32ex.NIIF_INFO,
+	}
+	copy(nid.SzInfoTitle[:len(nid.SzInfoTitle)-1], windows.StringToUTF16("RectangleWin"))
+	copy(nid.SzInfo[:len(nid.SzInfo)-1], windows.StringToUTF16(text))
+	if !w32ex.ShellNotifyIcon(w32ex.NIM_MODIFY, &nid) {
+		fmt.Printf("warn: notify: Shell_NotifyIcon failed:%d\n", w32.GetLastError())
+	}
+}
+
+// quietMode is toggled from the tray menu to hide notifications.
+var quietMode bool
+
+// isQuiet reports whether notifications are hidden at the given time, because
+// quiet mode is on or it is within the configured quiet hours.
+func isQuiet(t time.Time) bool {
+	if quietMode {
+		return true
+	}
+	q := config.QuietHours
+	if q.Start == "" {
+		return false
+	}
+	start, _ := parseClock(q.Start) // already checked by Config.validate
+	end, _ := parseClock(q.End)
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return start <= now && now < end
+	}
+	return now >= start || now < end // overnight, e.g. 22:00-07:00
+}
+
+// parseClock parses a "HH:MM" time of day into minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be like \"22:30\"", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// trayWindow finds the hidden window of this process owning the tray icon.
+func trayWindow() w32.HWND {
+	var out w32.HWND
+	w32.EnumWindows(func(h w32.HWND) bool {
+		if _, pid := w32.GetWindowThreadProcessId(h); int(pid) != os.Getpid() {
+			return true
+		}
+		if c, ok := w32.GetClassName(h); ok && c == systrayClassName {
+			out = h
+			return false
+		}
+		return true
+	})
+	return out
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// ownedWindows returns the visible, non-minimized windows owned by the window,
+// such as detached tool windows.
+func ownedWindows(owner w32.HWND) []w32.HWND {
+	var out []w32.HWND
+	w32.EnumWindows(func(h w32.HWND) bool {
+		if w32.GetWindow(h, w32.GW_OWNER) == owner && w32.IsWindowVisible(h) && !w32ex.IsIconic(h) {
+			out = append(out, h)
+		}
+		return true
+	})
+	return out
+}
+
+// moveOwnedWindows moves the windows owned by the window along with it, if
+// the app is configured with moveOwnedWindows. from and to are the window
+// rect of the owner before and after it was moved. Owned windows are
+// translated by the same offset, and if clampOwnedWindows is set, kept inside
+// the new rect of the owner.
+func moveOwnedWindows(owner w32.HWND, from, to w32.RECT) {
+	if _, ok := matchApp(config.MoveOwnedWindows, owner); !ok {
+		return
+	}
+	dx, dy := to.Left-from.Left, to.Top-from.Top
+	for _, h := range ownedWindows(owner) {
+		rect := w32.GetWindowRect(h)
+		if rect == nil {
+			fmt.Printf("warn: owned window 0x%x: failed to GetWindowRect:%d\n", h, w32.GetLastError())
+			continue
+		}
+		newPos := w32.RECT{Left: rect.Left + dx, Top: rect.Top + dy, Right: rect.Right + dx, Bottom: rect.Bottom + dy}
+		if config.ClampOwnedWindows {
+			newPos = clamp(newPos, to)
+		}
+		if sameRect(rect, &newPos) {
+			continue
+		}
+		fmt.Printf("> moving owned window 0x%x %q to: %#v\n", h, w32.GetWindowText(h), newPos)
+		if err := setWindowPos(h, newPos); err != nil {
+			fmt.Printf("warn: owned window 0x%x: %v\n", h, err)
+		}
+	}
+}
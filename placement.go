@@ -0,0 +1,117 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// maximizedPlacements are the placements of windows that were snapped while
+// maximized, which restorePlacement puts them back to. Besides the maximized
+// state, they hold the normal position the windows had before they were
+// maximized.
+var maximizedPlacements = make(map[w32.HWND]w32.WINDOWPLACEMENT)
+
+// rememberMaximized saves the placement of the window if it is maximized and
+// configured to be remembered, before it loses it by being snapped.
+func rememberMaximized(hwnd w32.HWND) {
+	if !config.RememberMaximized || !w32ex.IsZoomed(hwnd) {
+		return
+	}
+	var p w32.WINDOWPLACEMENT
+	if !w32.GetWindowPlacement(hwnd, &p) {
+		fmt.Printf("warn: failed to GetWindowPlacement:%d\n", w32.GetLastError())
+		return
+	}
+	for h := range maximizedPlacements {
+		if !w32.IsWindow(h) {
+			delete(maximizedPlacements, h)
+		}
+	}
+	maximizedPlacements[hwnd] = p
+	fmt.Printf("> remembered maximized placement, normal position: %#v\n", p.RcNormalPosition)
+}
+
+// restorePlacement maximizes the window again if it was snapped while
+// maximized, with the normal position it had before that.
+func restorePlacement(hwnd w32.HWND) (bool, error) {
+	p, ok := maximizedPlacements[hwnd]
+	if !ok {
+		fmt.Printf("no remembered placement for window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	delete(maximizedPlacements, hwnd)
+	fmt.Printf("> restoring placement, normal position: %#v\n", p.RcNormalPosition)
+	if !w32.SetWindowPlacement(hwnd, &p) {
+		return false, fmt.Errorf("failed to SetWindowPlacement:%d", w32.GetLastError())
+	}
+	markResized(hwnd)
+	return true, nil
+}
+
+// movedWindow is a window moved to another monitor, and its placement before
+// that.
+type movedWindow struct {
+	hwnd      w32.HWND
+	placement w32.WINDOWPLACEMENT
+}
+
+// monitorMove are the windows last moved to another monitor together (e.g.
+// both windows swapped by promoteToPrimary), the one moved by hand first.
+var monitorMove []movedWindow
+
+// rememberMonitorMove saves the placement of the windows before they are moved
+// to another monitor, for undoMonitorMove.
+func rememberMonitorMove(hwnds ...w32.HWND) {
+	monitorMove = nil
+	for _, hwnd := range hwnds {
+		var p w32.WINDOWPLACEMENT
+		if !w32.GetWindowPlacement(hwnd, &p) {
+			fmt.Printf("warn: failed to GetWindowPlacement:%d\n", w32.GetLastError())
+			continue
+		}
+		monitorMove = append(monitorMove, movedWindow{hwnd, p})
+	}
+}
+
+// undoMonitorMove puts the windows last moved to another monitor back where
+// they were, maximized if they were, and focuses the first one.
+func undoMonitorMove() (bool, error) {
+	moved := monitorMove
+	monitorMove = nil
+	var undone []w32.HWND
+	for _, m := range moved {
+		if !w32.IsWindow(m.hwnd) {
+			continue
+		}
+		p := m.placement
+		fmt.Printf("> undoing monitor move of window 0x%x %q, normal position: %#v\n", m.hwnd, w32.GetWindowText(m.hwnd), p.RcNormalPosition)
+		if !w32.SetWindowPlacement(m.hwnd, &p) {
+			return len(undone) > 0, fmt.Errorf("failed to SetWindowPlacement:%d", w32.GetLastError())
+		}
+		markResized(m.hwnd)
+		undone = append(undone, m.hwnd)
+	}
+	if len(undone) == 0 {
+		fmt.Println("no monitor move to undo")
+		return false, nil
+	}
+	setForeground(undone[0])
+	return true, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/getlantern/systray"
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// presentationSizes are the sizes a window is centered at in presentation
+// mode, largest first. The first one that fits the work area is used.
+var presentationSizes = []struct{ w, h int32 }{{1920, 1080}, {1600, 900}, {1280, 720}}
+
+// presentationState is the state of the window pinned in presentation mode
+// before the mode was turned on.
+type presentationState struct {
+	hwnd      w32.HWND
+	rect      w32.RECT
+	maximized bool
+	topmost   bool
+}
+
+var (
+	// presentation is non-nil while presentation mode is on. Automatic
+	// behaviors are paused and, if configured, notifications are hidden.
+	presentation *presentationState
+
+	presentationMenuItem *systray.MenuItem
+)
+
+// togglePresentationMode turns on presentation mode, pinning the window on
+// top of the others at the center of its monitor, or turns it off, putting
+// the pinned window back the way it was.
+func togglePresentationMode(hwnd w32.HWND) (bool, error) {
+	if presentation != nil {
+		p := presentation
+		presentation = nil
+		updatePresentationMenu()
+		fmt.Println("presentation mode off")
+		if !w32.IsWindow(p.hwnd) {
+			return false, nil
+		}
+		if !p.topmost && !w32.SetWindowPos(p.hwnd, w32.HWND_NOTOPMOST, 0, 0, 0, 0, w32.SWP_NOMOVE|w32.SWP_NOSIZE|w32.SWP_NOACTIVATE) {
+			return false, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+		}
+		if err := setWindowPos(p.hwnd, p.rect); err != nil {
+			return false, err
+		}
+		if p.maximized && !w32.ShowWindow(p.hwnd, w32.SW_MAXIMIZE) {
+			return false, fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
+		}
+		return true, nil
+	}
+
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	maximized := w32ex.IsZoomed(hwnd)
+	if maximized && !w32.ShowWindow(hwnd, w32.SW_RESTORE) {
+		return false, fmt.Errorf("failed to ShowWindow:%d", w32.GetLastError())
+	}
+	rect := w32.GetWindowRect(hwnd)
+	if rect == nil {
+		return false, fmt.Errorf("failed to GetWindowRect:%d", w32.GetLastError())
+	}
+	presentation = &presentationState{
+		hwnd:      hwnd,
+		rect:      *rect,
+		maximized: maximized,
+		topmost:   w32.GetWindowLong(hwnd, GWL_EXSTYLE)&w32.WS_EX_TOPMOST != 0,
+	}
+	updatePresentationMenu()
+	fmt.Printf("presentation mode on, pinning window 0x%x %q\n", hwnd, w32.GetWindowText(hwnd))
+
+	if _, err := resize(hwnd, presentationZone); err != nil {
+		return false, err
+	}
+	if !w32.SetWindowPos(hwnd, w32.HWND_TOPMOST, 0, 0, 0, 0, w32.SWP_NOMOVE|w32.SWP_NOSIZE|w32.SWP_NOACTIVATE) {
+		return false, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+	}
+	return true, nil
+}
+
+// presentationZone centers the window at the largest of presentationSizes
+// fitting the work area.
+func presentationZone(disp, cur w32.RECT) w32.RECT {
+	size := w32.RECT{Right: disp.Width(), Bottom: disp.Height()}
+	for _, s := range presentationSizes {
+		if s.w <= disp.Width() && s.h <= disp.Height() {
+			size = w32.RECT{Right: s.w, Bottom: s.h}
+			break
+		}
+	}
+	return center(disp, size)
+}
+
+func updatePresentationMenu() {
+	if presentationMenuItem == nil {
+		return
+	}
+	if presentation != nil {
+		presentationMenuItem.Check()
+	} else {
+		presentationMenuItem.Uncheck()
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// TilingPreset is a named arrangement of the windows on a monitor: the first
+// Masters of them in the Master zone, and the others stacked in the rest of
+// the monitor, bound to HotKey to apply it.
+type TilingPreset struct {
+	Name    string        `json:"name"`
+	Master  string        `json:"master"`  // zone, see zones
+	Masters int           `json:"masters"` // number of windows in the master zone, 1 if 0
+	Stack   string        `json:"stack"`   // "rows" or "columns", stackArrangement if empty
+	HotKey  HotKeyBinding `json:"hotkey"`
+}
+
+const (
+	// tilingPresetID is the hotkey id of the first tiling preset, whose reverse
+	// hotkeys rotating the master take the ids 100 above theirs.
+	tilingPresetID   = 210
+	maxTilingPresets = 90
+)
+
+// presetOrders are the windows each monitor was last tiled with by a preset,
+// the masters first, for rotating the master.
+var presetOrders = make(map[w32.HMONITOR][]w32.HWND)
+
+func (p TilingPreset) actionName() string { return "tilingPreset:" + p.Name }
+
+func (p TilingPreset) masters() int {
+	if p.Masters == 0 {
+		return 1
+	}
+	return p.Masters
+}
+
+func (p TilingPreset) stack() string {
+	if p.Stack == "" {
+		return config.StackArrangement
+	}
+	return p.Stack
+}
+
+// tilingPresetHotKeys returns the hotkeys applying the configured presets, and
+// the reverse hotkeys rotating the master by action name.
+func tilingPresetHotKeys() ([]HotKey, map[string]func()) {
+	var hks []HotKey
+	rotate := make(map[string]func())
+	for i, p := range config.TilingPresets {
+		p := p
+		mod, vk, err := p.HotKey.parse()
+		if err != nil {
+			// already checked by Config.validate
+			panic(err)
+		}
+		hks = append(hks, HotKey{id: tilingPresetID + i, name: p.actionName(), mod: mod | MOD_NOREPEAT, vk: vk,
+			callback: onForeground("tiling preset", func(hwnd w32.HWND) (bool, error) { return applyTilingPreset(hwnd, p, false) })})
+		rotate[p.actionName()] = onForeground("tiling preset", func(hwnd w32.HWND) (bool, error) { return applyTilingPreset(hwnd, p, true) })
+	}
+	return hks, rotate
+}
+
+// applyTilingPreset tiles the windows on the monitor of the window with the
+// preset, with the window as the (first) master. With rotate, the windows are
+// instead tiled in the order the preset last tiled them in on the monitor,
+// moved by one so that the first stacked window becomes the last master and
+// the first master the last stacked window.
+func applyTilingPreset(hwnd w32.HWND, p TilingPreset, rotate bool) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	mon, disp, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	master := zoneFor(hwnd, p.Master)(disp, withoutGap(frame, disp, config.Gap))
+	rest, ok := complement(disp, master)
+	if !ok {
+		return false, fmt.Errorf("zone %q does not leave a rectangle for the stacked windows", p.Master)
+	}
+
+	var ws []w32.HWND
+	for _, w := range capWindows("Tiling preset", tileableWindows(orderZ, mon), hwnd) {
+		ws = append(ws, w.hwnd)
+	}
+	if rotate {
+		ws = presetOrder(presetOrders[mon], ws)
+		if len(ws) > 1 {
+			ws = append(ws[1:], ws[0])
+		}
+	} else {
+		ws = append([]w32.HWND{hwnd}, removeWindow(ws, hwnd)...)
+	}
+
+	n := p.masters()
+	if n > len(ws) {
+		n = len(ws)
+	}
+	cells := splitRect(master, n, p.stack() == stackColumns)
+	if len(ws) > n {
+		cells = append(cells, splitRect(rest, len(ws)-n, p.stack() == stackColumns)...)
+	}
+	fmt.Printf("> tiling preset %q: %d master(s) in %s + %d window(s) as %s in %#v (rotate=%v)\n",
+		p.Name, n, p.Master, len(ws)-n, p.stack(), rest, rotate)
+	presetOrders[mon] = ws
+	return tile(ws, cells, disp)
+}
+
+// presetOrder returns the windows in the order of the previous ones, followed
+// by the windows that weren't among them in their own order.
+func presetOrder(prev, ws []w32.HWND) []w32.HWND {
+	var out []w32.HWND
+	for _, h := range prev {
+		if containsHWND(ws, h) {
+			out = append(out, h)
+		}
+	}
+	for _, h := range ws {
+		if !containsHWND(out, h) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func removeWindow(ws []w32.HWND, hwnd w32.HWND) []w32.HWND {
+	var out []w32.HWND
+	for _, h := range ws {
+		if h != hwnd {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func containsHWND(ws []w32.HWND, hwnd w32.HWND) bool {
+	for _, h := range ws {
+		if h == hwnd {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// primaryMonitor returns the primary monitor, or 0 if it isn't found.
+func primaryMonitor() w32.HMONITOR {
+	var out w32.HMONITOR
+	EnumMonitors(func(d w32.HMONITOR) bool {
+		var v w32.MONITORINFO
+		if w32.GetMonitorInfo(d, &v) && v.DwFlags&w32.MONITORINFOF_PRIMARY != 0 {
+			out = d
+			return false
+		}
+		return true
+	})
+	return out
+}
+
+// promoteToPrimary swaps the window with the topmost window on the primary
+// monitor: each moves to the monitor of the other, in the zone the other was
+// in. If the primary monitor has no window, the window is moved to its center.
+func promoteToPrimary(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	primary := primaryMonitor()
+	if primary == 0 {
+		return false, fmt.Errorf("primary monitor not found")
+	}
+	mon, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	if mon == primary {
+		fmt.Println("promote to primary: window is already on the primary monitor")
+		return false, nil
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	ws := zonableWindows(orderZ, primary)
+	if len(ws) == 0 {
+		fmt.Println("> promote to primary: no window on the primary monitor, moving to its center")
+		rememberMonitorMove(hwnd)
+		return moveToMonitor(hwnd, primary)
+	}
+	other := ws[0]
+	rememberMonitorMove(hwnd, other.hwnd)
+	_, primaryArea, err := workArea(other.hwnd)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("> promote to primary: swapping with window 0x%x %q\n", other.hwnd, w32.GetWindowText(other.hwnd))
+
+	// move to the other monitor first, for windows to get scaled to its DPI
+	swap := func(h w32.HWND, to w32.HMONITOR, zone, from w32.RECT) (bool, error) {
+		if _, err := moveToMonitor(h, to); err != nil {
+			return false, err
+		}
+		return resize(h, func(disp, _ w32.RECT) w32.RECT { return mapRect(zone, from, disp) })
+	}
+	okA, err := swap(hwnd, primary, withoutGap(other.frame, primaryArea, config.Gap), primaryArea)
+	if err != nil {
+		return false, err
+	}
+	okB, err := swap(other.hwnd, mon, withoutGap(frame, area, config.Gap), area)
+	if err != nil {
+		return false, err
+	}
+	return okA || okB, nil
+}
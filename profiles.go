@@ -0,0 +1,54 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "sort"
+
+// hotKeyProfiles are the presets of hotkeys that the profile config selects,
+// by action name. The hotkeys of actions missing from a profile are the
+// defaults.
+var hotKeyProfiles = map[string]map[string]HotKeyBinding{
+	"default": nil,
+
+	// like Rectangle on macOS, with Ctrl + Alt for Ctrl + Option
+	"rectangle": {
+		"cycleLeft":    {Mods: []string{"ctrl", "alt"}, Key: "left"},
+		"cycleRight":   {Mods: []string{"ctrl", "alt"}, Key: "right"},
+		"cycleTop":     {Mods: []string{"ctrl", "alt"}, Key: "up"},
+		"cycleBottom":  {Mods: []string{"ctrl", "alt"}, Key: "down"},
+		"maximize":     {Mods: []string{"ctrl", "alt"}, Key: "enter"},
+		"cycleThirds":  {Mods: []string{"ctrl", "alt"}, Key: "d"},
+		"cycleCorners": {Mods: []string{"ctrl", "alt"}, Key: "u"},
+		"toggleZoom":   {Mods: []string{"ctrl", "alt"}, Key: "backspace"},
+	},
+
+	// like PowerToys FancyZones overriding Windows Snap, which needs
+	// winKeyHook to take the keys over from Windows
+	"powertoys": {
+		"cycleLeft":   {Mods: []string{"win"}, Key: "left"},
+		"cycleRight":  {Mods: []string{"win"}, Key: "right"},
+		"maximize":    {Mods: []string{"win"}, Key: "up"},
+		"nextMonitor": {Mods: []string{"win", "shift"}, Key: "right"},
+	},
+}
+
+func profileNames() []string {
+	out := make([]string, 0, len(hotKeyProfiles))
+	for k := range hotKeyProfiles {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
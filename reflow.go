@@ -0,0 +1,171 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+)
+
+const (
+	listenerClassName = "RectangleWinListener"
+	spiSetWorkArea    = 0x002F // WM_SETTINGCHANGE wParam when a work area changed
+)
+
+// placedZone is where RectangleWin last placed a window.
+type placedZone struct {
+	mon  w32.HMONITOR
+	work w32.RECT // work area of mon at the time
+	zone w32.RECT // zone of the window, without the gap around it
+}
+
+var (
+	// windowZones are the zones windows were last placed in by resize.
+	windowZones = make(map[w32.HWND]placedZone)
+
+	// previousZones are the zones windows were placed in before the ones in
+	// windowZones.
+	previousZones = make(map[w32.HWND]placedZone)
+)
+
+// rememberZone records the zone the window was placed in, if it is on the
+// monitor of the work area the zone was computed for.
+func rememberZone(hwnd w32.HWND, mon w32.HMONITOR, work, zone w32.RECT) {
+	for h := range windowZones {
+		if !w32.IsWindow(h) {
+			delete(windowZones, h)
+			delete(previousZones, h)
+		}
+	}
+	if w32.MonitorFromRect(&zone, w32.MONITOR_DEFAULTTONEAREST) != mon {
+		delete(windowZones, hwnd)
+		return
+	}
+	if z, ok := windowZones[hwnd]; ok && !matchesZone(mapRect(z.zone, z.work, work), zone, config.ZoneTolerance) {
+		previousZones[hwnd] = z
+	}
+	windowZones[hwnd] = placedZone{mon: mon, work: work, zone: zone}
+}
+
+// toggleZones moves the window back to the zone it was placed in before the
+// last one, or to the last one if it was moved away since. The zones are
+// mapped to the current work area of the window proportionally.
+func toggleZones(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	last, ok := windowZones[hwnd]
+	prev, okPrev := previousZones[hwnd]
+	if !ok || !okPrev {
+		fmt.Printf("toggle zones: window 0x%x %q has not been in two zones\n", hwnd, w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	_, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	to := last
+	if matchesZone(frame, withGap(mapRect(last.zone, last.work, area), area, config.Gap), config.ZoneTolerance) {
+		to = prev
+	}
+	fmt.Printf("> toggle zones: to %#v\n", to.zone)
+	return resize(hwnd, func(disp, _ w32.RECT) w32.RECT { return mapRect(to.zone, to.work, disp) })
+}
+
+// reflowWindows fits the windows still in the zone they were placed in to the
+// current work area of their monitor, scaling the zone proportionally. Windows
+// moved or resized since are left alone.
+func reflowWindows() {
+	if presentation != nil {
+		return
+	}
+	for h, z := range windowZones {
+		if !w32.IsWindow(h) || w32.MonitorFromWindow(h, w32.MONITOR_DEFAULTTONEAREST) != z.mon {
+			continue
+		}
+		var monInfo w32.MONITORINFO
+		if !w32.GetMonitorInfo(z.mon, &monInfo) {
+			continue
+		}
+		if monInfo.RcWork = taskbarWorkArea(z.mon, monInfo); monInfo.RcWork == z.work {
+			continue
+		}
+		frame, err := visibleFrame(h)
+		if err != nil || !matchesZone(frame, withGap(z.zone, z.work, config.Gap), config.ZoneTolerance) {
+			continue
+		}
+		z := z
+		fmt.Printf("> reflowing window 0x%x %q from work area %#v to %#v\n", h, w32.GetWindowText(h), z.work, monInfo.RcWork)
+		if _, err := resize(h, func(disp, _ w32.RECT) w32.RECT { return mapRect(z.zone, z.work, disp) }); err != nil {
+			fmt.Printf("warn: reflow: window 0x%x: %v\n", h, err)
+		}
+	}
+}
+
+// mapRect maps r from one area to another, proportionally.
+func mapRect(r, from, to w32.RECT) w32.RECT {
+	if from.Width() == 0 || from.Height() == 0 {
+		return r
+	}
+	return w32.RECT{
+		Left:   to.Left + mulDivRound(r.Left-from.Left, to.Width(), from.Width()),
+		Top:    to.Top + mulDivRound(r.Top-from.Top, to.Height(), from.Height()),
+		Right:  to.Left + mulDivRound(r.Right-from.Left, to.Width(), from.Width()),
+		Bottom: to.Top + mulDivRound(r.Bottom-from.Top, to.Height(), from.Height()),
+	}
+}
+
+// listening is set once the listener window is created.
+var listening bool
+
+// createListenerWindow creates a hidden window receiving the messages
+// broadcast to top-level windows, which thread message queues don't get.
+func createListenerWindow() error {
+	instance := w32.GetModuleHandle("")
+	class := syscall.StringToUTF16Ptr(listenerClassName)
+	wc := w32.WNDCLASSEX{
+		WndProc:   syscall.NewCallback(listenerWindowProc),
+		Instance:  instance,
+		ClassName: class,
+	}
+	wc.Size = uint32(unsafe.Sizeof(wc))
+	if w32.RegisterClassEx(&wc) == 0 {
+		return fmt.Errorf("failed to RegisterClassEx:%d", w32.GetLastError())
+	}
+	if w32.CreateWindowEx(w32.WS_EX_TOOLWINDOW, class, nil, w32.WS_POPUP, 0, 0, 0, 0, 0, 0, instance, nil) == 0 {
+		return fmt.Errorf("failed to CreateWindowEx:%d", w32.GetLastError())
+	}
+	listening = true
+	return nil
+}
+
+func listenerWindowProc(hwnd w32.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	if msg == w32.WM_SETTINGCHANGE || msg == w32.WM_DISPLAYCHANGE {
+		invalidateTaskbars() // moved, resized or auto-hidden, or monitors changed
+	}
+	if msg == w32.WM_SETTINGCHANGE && wParam == spiSetWorkArea && config.ReflowOnWorkAreaChange {
+		fmt.Println("work area changed")
+		reflowWindows()
+	}
+	return w32.DefWindowProc(hwnd, msg, wParam, lParam)
+}
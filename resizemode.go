@@ -0,0 +1,156 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// minResizeModeSize is the smallest width and height resize mode shrinks
+// windows to.
+const minResizeModeSize = 100
+
+// resizeMode is a window being resized one edge at a time with the arrow keys.
+type resizeMode struct {
+	hwnd w32.HWND
+	from w32.RECT // window rect when resize mode started, to cancel
+	hook w32.HHOOK
+}
+
+var resizing *resizeMode
+
+// toggleResizeMode starts resizing the window with the arrow keys, which are
+// taken over by a low-level keyboard hook until resize mode ends: the arrows
+// move the bottom and right edges of the window by resizeModeStep pixels, and
+// with Shift the top and left edges. Enter (or the hotkey again) ends resize
+// mode, and Escape puts the window back the way it was.
+func toggleResizeMode(hwnd w32.HWND) (bool, error) {
+	if resizing != nil {
+		exitResizeMode(true)
+		return true, nil
+	}
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	if w32ex.IsZoomed(hwnd) && !w32.ShowWindow(hwnd, w32.SW_SHOWNORMAL) {
+		return false, fmt.Errorf("failed to normalize window ShowWindow:%d", w32.GetLastError())
+	}
+	rect := w32.GetWindowRect(hwnd)
+	if rect == nil {
+		return false, fmt.Errorf("failed to GetWindowRect:%d", w32.GetLastError())
+	}
+	hook := w32.SetWindowsHookEx(w32.WH_KEYBOARD_LL, resizeModeHookProc, w32.GetModuleHandle(""), 0)
+	if hook == 0 {
+		return false, fmt.Errorf("failed to SetWindowsHookEx:%d", w32.GetLastError())
+	}
+	resizing = &resizeMode{hwnd: hwnd, from: *rect, hook: hook}
+	fmt.Printf("> resize mode started for window 0x%x %q\n", hwnd, w32.GetWindowText(hwnd))
+	notify("Resize mode: arrows move the bottom and right edges, Shift + arrows the top and left ones. Enter to finish, Esc to cancel.")
+	return true, nil
+}
+
+// exitResizeMode ends resize mode, putting the window back where it was unless
+// keep is set.
+func exitResizeMode(keep bool) {
+	r := resizing
+	if r == nil {
+		return
+	}
+	resizing = nil
+	if !w32.UnhookWindowsHookEx(r.hook) {
+		fmt.Printf("warn: resize mode: failed to UnhookWindowsHookEx:%d\n", w32.GetLastError())
+	}
+	fmt.Printf("> resize mode ended (keep=%v)\n", keep)
+	if !keep && w32.IsWindow(r.hwnd) {
+		if err := setWindowPos(r.hwnd, r.from); err != nil {
+			fmt.Printf("warn: resize mode: %v\n", err)
+		}
+	}
+}
+
+func resizeModeHookProc(code int, wParam w32.WPARAM, lParam w32.LPARAM) w32.LRESULT {
+	r := resizing
+	if code < 0 || r == nil {
+		return w32.CallNextHookEx(0, code, wParam, lParam)
+	}
+	kb := *(**w32.KBDLLHOOKSTRUCT)(unsafe.Pointer(&lParam))
+	if kb.Flags&llkhfInjected != 0 {
+		return w32.CallNextHookEx(r.hook, code, wParam, lParam)
+	}
+	switch kb.VkCode {
+	case w32.VK_LEFT, w32.VK_RIGHT, w32.VK_UP, w32.VK_DOWN, w32.VK_RETURN, w32.VK_ESCAPE:
+	default:
+		return w32.CallNextHookEx(r.hook, code, wParam, lParam)
+	}
+	if wParam != w32.WM_KEYDOWN && wParam != w32.WM_SYSKEYDOWN {
+		return 1 // swallow the release of the keys taken over too
+	}
+	vk, shift := kb.VkCode, w32.GetAsyncKeyState(w32.VK_SHIFT)&0x8000 != 0
+	runOnMainThread(func() { // hook procs must return quickly
+		switch vk {
+		case w32.VK_RETURN:
+			exitResizeMode(true)
+		case w32.VK_ESCAPE:
+			exitResizeMode(false)
+		default:
+			resizeEdge(vk, shift)
+		}
+	})
+	return 1
+}
+
+// resizeEdge moves the edge of the window in resize mode for the arrow key.
+func resizeEdge(vk w32.DWORD, shift bool) {
+	r := resizing
+	if r == nil {
+		return
+	}
+	if !w32.IsWindow(r.hwnd) || w32.GetForegroundWindow() != r.hwnd {
+		fmt.Println("> resize mode: window closed or no longer in the foreground")
+		exitResizeMode(true)
+		return
+	}
+	rect := w32.GetWindowRect(r.hwnd)
+	if rect == nil {
+		fmt.Printf("warn: resize mode: failed to GetWindowRect:%d\n", w32.GetLastError())
+		return
+	}
+	step := config.ResizeModeStep
+	edge := map[w32.DWORD]*int32{w32.VK_LEFT: &rect.Right, w32.VK_RIGHT: &rect.Right, w32.VK_UP: &rect.Bottom, w32.VK_DOWN: &rect.Bottom}
+	if shift {
+		edge = map[w32.DWORD]*int32{w32.VK_LEFT: &rect.Left, w32.VK_RIGHT: &rect.Left, w32.VK_UP: &rect.Top, w32.VK_DOWN: &rect.Top}
+	}
+	if vk == w32.VK_LEFT || vk == w32.VK_UP {
+		step = -step
+	}
+	*edge[vk] += step
+	if rect.Width() < minResizeModeSize || rect.Height() < minResizeModeSize {
+		return
+	}
+	if err := setWindowPos(r.hwnd, *rect); err != nil {
+		fmt.Printf("warn: resize mode: %v\n", err)
+		return
+	}
+	markResized(r.hwnd)
+	if frame, err := visibleFrame(r.hwnd); err == nil {
+		notify(fmt.Sprintf("%d x %d", frame.Width(), frame.Height()))
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// ResnapConfig configures which zones resnap snaps windows back to.
+type ResnapConfig struct {
+	Zones       []string `json:"zones"`       // all zones if empty
+	Tolerance   int32    `json:"tolerance"`   // max pixels off on each edge
+	NearestHalf bool     `json:"nearestHalf"` // fallback if no zone is close
+}
+
+// resnap snaps the window exactly into the zone it is closest to, if each of
+// its edges is within the resnap tolerance of that zone, to clean up windows
+// moved or resized slightly by hand. Otherwise, it is snapped to the left or
+// right half it is mostly in, if nearestHalf is set.
+func resnap(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	_, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	cell := withoutGap(frame, area, config.Gap)
+
+	names := config.Resnap.Zones
+	if len(names) == 0 {
+		for name := range zones {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	s := splitFor(hwnd)
+	var best string
+	var bestDist int32
+	for _, name := range names {
+		z := zones[name](s, area, cell)
+		if !matchesZone(cell, z, config.Resnap.Tolerance) {
+			continue
+		}
+		if d := edgeDistance(cell, z); best == "" || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		if !config.Resnap.NearestHalf {
+			fmt.Printf("resnap: window 0x%x %q is not close to a zone\n", hwnd, w32.GetWindowText(hwnd))
+			return false, nil
+		}
+		best = "leftHalf"
+		if cell.Left+cell.Width()/2 >= area.Left+area.Width()/2 {
+			best = "rightHalf"
+		}
+		fmt.Printf("> resnap: not close to a zone, falling back to the nearest half\n")
+	}
+	fmt.Printf("> resnap: snapping window 0x%x %q to %s (%dpx off)\n", hwnd, w32.GetWindowText(hwnd), best, bestDist)
+	return resize(hwnd, zoneFor(hwnd, best))
+}
+
+// edgeDistance is the sum of the distances between the edges of a and b.
+func edgeDistance(a, b w32.RECT) int32 {
+	abs := func(v int32) int32 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	return abs(a.Left-b.Left) + abs(a.Top-b.Top) + abs(a.Right-b.Right) + abs(a.Bottom-b.Bottom)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getlantern/systray"
+	"github.com/gonutz/w32/v2"
+)
+
+// RoleLayout is a named set of roles, the first of which goes to the window
+// the layout is applied to.
+type RoleLayout struct {
+	Name  string       `json:"name"`
+	Roles []LayoutRole `json:"roles"`
+}
+
+// LayoutRole is a zone filled by a window of an app. The app of the first
+// role of a layout is not used.
+type LayoutRole struct {
+	Name string     `json:"name"`
+	Zone string     `json:"zone"`
+	App  AppMatcher `json:"app"`
+}
+
+var (
+	// selectedLayout is the name of the layout chosen in the tray menu, or
+	// empty for the configured one.
+	selectedLayout  string
+	layoutMenuItems []*systray.MenuItem
+)
+
+// currentLayout returns the layout applyLayout applies.
+func currentLayout() (RoleLayout, bool) {
+	name := selectedLayout
+	if name == "" {
+		name = config.Layout
+	}
+	for _, l := range config.Layouts {
+		if l.Name == name || name == "" {
+			return l, true
+		}
+	}
+	return RoleLayout{}, false
+}
+
+// applyLayout puts the window in the first role of the current layout, and
+// windows of the apps of the other roles in theirs, on the monitor of the
+// window. Of the windows of an app, the one RectangleWin resized most recently
+// is taken, or if it resized none of them, the topmost one (see orderRecency).
+// Roles with no open window of their app are left empty and reported.
+func applyLayout(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	l, ok := currentLayout()
+	if !ok {
+		fmt.Println("no layouts configured")
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
+	fmt.Printf("> layout %q: window 0x%x %q is %s\n", l.Name, hwnd, w32.GetWindowText(hwnd), l.Roles[0].Name)
+	resized, err := resize(hwnd, zoneFor(hwnd, l.Roles[0].Zone))
+	if err != nil {
+		return false, err
+	}
+
+	windows := zonableWindows(orderRecency, 0)
+	used := map[w32.HWND]bool{hwnd: true}
+	var missing []string
+	for _, r := range l.Roles[1:] {
+		var w w32.HWND
+		for _, c := range windows {
+			if !used[c.hwnd] && r.App.matches(c.hwnd) {
+				w = c.hwnd
+				break
+			}
+		}
+		if w == 0 {
+			fmt.Printf("> layout %q: no window of app (%s) for %s\n", l.Name, r.App, r.Name)
+			missing = append(missing, r.Name)
+			continue
+		}
+		used[w] = true
+		fmt.Printf("> layout %q: window 0x%x %q is %s\n", l.Name, w, w32.GetWindowText(w), r.Name)
+		if w32.MonitorFromWindow(w, w32.MONITOR_DEFAULTTONEAREST) != mon {
+			if _, err := moveToMonitor(w, mon); err != nil {
+				return resized, fmt.Errorf("window 0x%x: %w", w, err)
+			}
+		}
+		ok, err := resize(w, zoneFor(w, r.Zone))
+		if err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", w, err)
+		}
+		resized = resized || ok
+	}
+	if len(missing) > 0 {
+		notify(fmt.Sprintf("Layout %q: no window for %s.", l.Name, strings.Join(missing, ", ")))
+	}
+	return resized, nil
+}
+
+// selectLayout makes the layout of the nth item of the tray menu the current
+// one.
+func selectLayout(n int) {
+	selectedLayout = config.Layouts[n].Name
+	fmt.Printf("> selected layout %q\n", selectedLayout)
+	updateLayoutMenu()
+}
+
+func updateLayoutMenu() {
+	cur, _ := currentLayout()
+	for i, m := range layoutMenuItems {
+		if config.Layouts[i].Name == cur.Name {
+			m.Check()
+		} else {
+			m.Uncheck()
+		}
+	}
+}
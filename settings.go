@@ -0,0 +1,132 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+
+	"github.com/gonutz/w32/v2"
+	"golang.org/x/sys/windows"
+)
+
+// settingsFile is the content of a file that settings are exported to, to be
+// imported on another machine.
+type settingsFile struct {
+	Config Config  `json:"config"`
+	Slots  []*slot `json:"slots,omitempty"` // saved slots, only if exported
+}
+
+// exportSettings writes the effective configuration, and if withSlots is set
+// the saved slots, to the file.
+func exportSettings(path string, withSlots bool) error {
+	v := settingsFile{Config: config}
+	if withSlots {
+		v.Slots = slots[:]
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("exported settings to %s\n", path)
+	return nil
+}
+
+// importSettings replaces the config file with the configuration in the
+// exported settings file, applied over the current configuration or, if
+// replace is set, over the defaults. The saved slots in it replace the current
+// ones. Nothing is changed if the file isn't valid. The configuration takes
+// effect when RectangleWin is restarted.
+func importSettings(path string, replace bool) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	c := defaultConfig()
+	if !replace {
+		if c, err = loadConfig(); err != nil {
+			return err
+		}
+	}
+	v := settingsFile{Config: c}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := v.Config.validate(); err != nil {
+		return fmt.Errorf("invalid config in %s: %w", path, err)
+	}
+	if len(v.Slots) > numSlots {
+		return fmt.Errorf("invalid slots in %s: %d slots, at most %d", path, len(v.Slots), numSlots)
+	}
+
+	p, err := configPath()
+	if err != nil {
+		return err
+	}
+	if b, err = json.MarshalIndent(v.Config, "", "  "); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, b, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("imported config from %s to %s\n", path, p)
+	if v.Slots != nil {
+		slots = [numSlots]*slot{}
+		copy(slots[:], v.Slots)
+		updateSlotMenu()
+		if err := saveSlots(); err != nil {
+			return err
+		}
+		fmt.Printf("imported %d slots\n", len(v.Slots))
+	}
+	return nil
+}
+
+// settingsFileDialog shows a dialog to pick the file to save settings to, or
+// to open settings from, and returns its path.
+func settingsFileDialog(save bool) (string, bool) {
+	file := make([]uint16, w32.MAX_PATH)
+	copy(file, utf16.Encode([]rune("RectangleWin-settings.json")))
+	filter := utf16.Encode([]rune("JSON files (*.json)\x00*.json\x00All files\x00*.*\x00\x00"))
+	ext := utf16.Encode([]rune("json\x00"))
+	ofn := w32.OPENFILENAME{
+		Filter:  &filter[0],
+		File:    &file[0],
+		MaxFile: uint32(len(file)),
+		DefExt:  &ext[0],
+		Flags:   w32.OFN_NOCHANGEDIR | w32.OFN_PATHMUSTEXIST,
+	}
+	var ok bool
+	if save {
+		ofn.Flags |= w32.OFN_OVERWRITEPROMPT
+		ok = w32.GetSaveFileName(&ofn)
+	} else {
+		ofn.Flags |= w32.OFN_FILEMUSTEXIST
+		ok = w32.GetOpenFileName(&ofn)
+	}
+	if !ok {
+		return "", false // cancelled
+	}
+	return windows.UTF16ToString(file), true
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// SettleConfig configures correcting the windows of apps that move or resize
+// themselves slightly right after RectangleWin resized them.
+type SettleConfig struct {
+	Apps      []AppMatcher `json:"apps"`
+	DelayMs   int          `json:"delayMs"`   // wait before checking the window
+	Tolerance int32        `json:"tolerance"` // pixels off on each edge allowed
+}
+
+// settlingWindows are the rects windows of settle apps were last resized to,
+// for the check after the delay to skip windows resized again since.
+var settlingWindows = make(map[w32.HWND]w32.RECT)
+
+// settleWindow checks, after the settle delay, whether the window of a settle
+// app is still at the rect it was resized to, and moves it back there once if
+// it has moved itself away since.
+func settleWindow(hwnd w32.HWND, target w32.RECT) {
+	m, ok := matchApp(config.Settle.Apps, hwnd)
+	if !ok {
+		return
+	}
+	settlingWindows[hwnd] = target
+	go func() {
+		time.Sleep(time.Duration(config.Settle.DelayMs) * time.Millisecond)
+		runOnMainThread(func() {
+			if settlingWindows[hwnd] != target {
+				return // resized again since
+			}
+			delete(settlingWindows, hwnd)
+			rect := w32.GetWindowRect(hwnd)
+			if rect == nil || matchesZone(*rect, target, config.Settle.Tolerance) {
+				return
+			}
+			fmt.Printf("> settle: window 0x%x (%s) moved itself to %#v after being resized to %#v, correcting once\n", hwnd, m, *rect, target)
+			if err := setWindowPos(hwnd, target); err != nil {
+				fmt.Printf("warn: settle: %v\n", err)
+			}
+		})
+	}()
+}
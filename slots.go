@@ -0,0 +1,208 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/getlantern/systray"
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+const numSlots = 9
+
+// slot is a window position saved by the user.
+type slot struct {
+	Monitor string   `json:"monitor"` // device name of the monitor the window was on
+	Rect    w32.RECT `json:"rect"`    // window rect in screen coordinates
+	DPI     int32    `json:"dpi"`     // DPI of the monitor when the slot was saved
+}
+
+var (
+	slots         [numSlots]*slot
+	slotMenuItems []*systray.MenuItem
+	slotTurn      int // how many times cycleSlots moved lastResized
+)
+
+func slotsPath() (string, error) {
+	d, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "slots.json"), nil
+}
+
+func loadSlots() error {
+	p, err := slotsPath()
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var v []*slot
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	copy(slots[:], v)
+	return nil
+}
+
+func saveSlots() error {
+	p, err := slotsPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(slots, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o644)
+}
+
+// saveSlot saves the current position of the window to slot n (1-based).
+func saveSlot(hwnd w32.HWND, n int) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	rect := w32.GetWindowRect(hwnd)
+	if rect == nil {
+		return false, fmt.Errorf("failed to GetWindowRect:%d", w32.GetLastError())
+	}
+	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
+	slots[n-1] = &slot{Monitor: monitorDeviceName(mon), Rect: *rect, DPI: w32ex.GetDpiForMonitor(mon)}
+	fmt.Printf("> saved slot %d: %s\n", n, slots[n-1])
+	updateSlotMenu()
+	return true, saveSlots()
+}
+
+// recallSlot moves the window to the position saved in slot n (1-based). If
+// the monitor the slot was saved on is not connected, the position is clamped
+// to the nearest monitor. If the DPI of the monitor has changed since, the
+// size is scaled so that the window keeps its physical size.
+func recallSlot(hwnd w32.HWND, n int) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	s := slots[n-1]
+	if s == nil {
+		fmt.Printf("slot %d is empty\n", n)
+		return false, nil
+	}
+	newPos := s.Rect
+	mon := findMonitor(s.Monitor)
+	gone := mon == 0
+	if gone {
+		mon = w32.MonitorFromRect(&newPos, w32.MONITOR_DEFAULTTONEAREST)
+	}
+	var monInfo w32.MONITORINFO
+	if !w32.GetMonitorInfo(mon, &monInfo) {
+		return false, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
+	}
+	dpi := w32ex.GetDpiForMonitor(mon)
+	scaled := s.DPI != 0 && dpi != 0 && dpi != s.DPI
+	if scaled {
+		size := resizeForDpi(w32.RECT{Right: newPos.Width(), Bottom: newPos.Height()}, s.DPI, dpi)
+		newPos.Right = newPos.Left + size.Width()
+		newPos.Bottom = newPos.Top + size.Height()
+		fmt.Printf("> slot %d saved at DPI %d, scaled for DPI %d: %#v\n", n, s.DPI, dpi, newPos)
+	}
+	if gone || scaled {
+		newPos = clamp(newPos, monInfo.RcWork)
+		fmt.Printf("> slot %d clamped to monitor 0x%x: %#v\n", n, mon, newPos)
+	}
+
+	markResized(hwnd)
+	if sameRect(w32.GetWindowRect(hwnd), &newPos) {
+		fmt.Println("no resize")
+		return false, nil
+	}
+	fmt.Printf("> resizing to: %#v (W:%d,H:%d)\n", newPos, newPos.Width(), newPos.Height())
+	if m, ok := matchApp(config.SkipNormalize, hwnd); ok {
+		fmt.Printf("> skipping normalize for app (%s)\n", m)
+	} else if !w32.ShowWindow(hwnd, w32.SW_SHOWNORMAL) {
+		return false, fmt.Errorf("failed to normalize window ShowWindow:%d", w32.GetLastError())
+	}
+	if err := setWindowPos(hwnd, newPos); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// cycleSlots moves the window to the next saved slot on each call, starting
+// over from the first one when a different window is cycled.
+func cycleSlots(hwnd w32.HWND) (bool, error) {
+	var saved []int
+	for i, s := range slots {
+		if s != nil {
+			saved = append(saved, i+1)
+		}
+	}
+	if len(saved) == 0 {
+		fmt.Println("no saved slots to cycle")
+		return false, nil
+	}
+	if lastResized != hwnd {
+		slotTurn = 0
+	}
+	n := saved[slotTurn%len(saved)]
+	fmt.Printf("> cycling to slot %d\n", n)
+	ok, err := recallSlot(hwnd, n)
+	if err != nil {
+		return false, err
+	}
+	slotTurn++
+	return ok, nil
+}
+
+func (s *slot) String() string {
+	if s == nil {
+		return "(empty)"
+	}
+	return fmt.Sprintf("%dx%d at (%d,%d) on %s", s.Rect.Width(), s.Rect.Height(), s.Rect.Left, s.Rect.Top, s.Monitor)
+}
+
+// findMonitor returns the connected monitor with the given device name, or 0.
+func findMonitor(device string) w32.HMONITOR {
+	var out w32.HMONITOR
+	EnumMonitors(func(d w32.HMONITOR) bool {
+		if monitorDeviceName(d) == device {
+			out = d
+			return false
+		}
+		return true
+	})
+	return out
+}
+
+func updateSlotMenu() {
+	for i, m := range slotMenuItems {
+		m.SetTitle(fmt.Sprintf("%d: %s", i+1, slots[i]))
+	}
+}
@@ -14,21 +14,28 @@
 
 package main
 
-import "github.com/gonutz/w32/v2"
+import (
+	"fmt"
+	"sort"
 
-// TODO find a way to round up divisions consistently, otherwise we end up with off by 1px
+	"github.com/gonutz/w32/v2"
+)
+
+// Zones anchored to the right/bottom edge start where the complementary
+// left/top zone ends (e.g. rightOneThirds starts at the end of leftTwoThirds),
+// so that complementary zones tile exactly regardless of rounding.
 
 func toLeft(d w32.RECT, mul, div int32) w32.RECT {
 	return w32.RECT{
 		Left:   d.Left,
 		Top:    d.Top,
-		Right:  d.Left + (d.Width()*mul)/div,
+		Right:  d.Left + splitPoint(d.Width(), mul, div),
 		Bottom: d.Top + d.Height()}
 }
 
 func toRight(d w32.RECT, mul, div int32) w32.RECT {
 	return w32.RECT{
-		Left:   d.Left + d.Width() - d.Width()*mul/div,
+		Left:   d.Left + splitPoint(d.Width(), div-mul, div),
 		Top:    d.Top,
 		Right:  d.Left + d.Width(),
 		Bottom: d.Top + d.Height()}
@@ -39,37 +46,317 @@ func toTop(d w32.RECT, mul, div int32) w32.RECT {
 		Left:   d.Left,
 		Top:    d.Top,
 		Right:  d.Left + d.Width(),
-		Bottom: d.Top + d.Height()*mul/div}
+		Bottom: d.Top + splitPoint(d.Height(), mul, div)}
 }
 
 func toBottom(d w32.RECT, mul, div int32) w32.RECT {
 	return w32.RECT{
 		Left:   d.Left,
-		Top:    d.Top + d.Height() - d.Height()*mul/div,
+		Top:    d.Top + splitPoint(d.Height(), div-mul, div),
 		Right:  d.Left + d.Width(),
 		Bottom: d.Top + d.Height()}
 }
 
-func leftHalf(disp, _ w32.RECT) w32.RECT      { return toLeft(disp, 1, 2) }
-func leftOneThirds(disp, _ w32.RECT) w32.RECT { return toLeft(disp, 1, 3) }
-func leftTwoThirds(disp, _ w32.RECT) w32.RECT { return toLeft(disp, 2, 3) }
+// splitPoint returns where a length is split at mul/div of it. When it doesn't
+// divide evenly, it is rounded as configured by splitRounding, which decides
+// which side of the split gets the leftover pixel.
+func splitPoint(length, mul, div int32) int32 {
+	up := (length*mul + div - 1) / div
+	switch config.SplitRounding {
+	case roundStart:
+		return up
+	case roundNearest:
+		return mulDivRound(length, mul, div)
+	case roundOut:
+		if 2*mul < div { // before the center, where the zone before it is on the edge
+			return up
+		}
+	}
+	return length * mul / div
+}
+
+// split is the fraction mul/div of the work area the two-thirds zones take,
+// and the one-thirds zones take the rest.
+type split struct {
+	mul, div int32
+}
+
+// defaultSplit is used when no splitRatio is configured.
+var defaultSplit = split{2, 3}
+
+// splitFor returns the split configured for the app of the window, or the
+// global one.
+func splitFor(hwnd w32.HWND) split {
+	ratio := config.SplitRatio
+	for _, r := range config.AppSplitRatios {
+		if r.App.matches(hwnd) {
+			ratio = r.Ratio
+			break
+		}
+	}
+	if ratio == 0 {
+		return defaultSplit
+	}
+	return split{ratio, 100}
+}
+
+func leftHalf(disp, _ w32.RECT) w32.RECT                { return toLeft(disp, 1, 2) }
+func (s split) leftOneThirds(disp, _ w32.RECT) w32.RECT { return toLeft(disp, s.div-s.mul, s.div) }
+func (s split) leftTwoThirds(disp, _ w32.RECT) w32.RECT { return toLeft(disp, s.mul, s.div) }
 
-func topHalf(disp, _ w32.RECT) w32.RECT      { return toTop(disp, 1, 2) }
-func topOneThirds(disp, _ w32.RECT) w32.RECT { return toTop(disp, 1, 3) }
-func topTwoThirds(disp, _ w32.RECT) w32.RECT { return toTop(disp, 2, 3) }
+func topHalf(disp, _ w32.RECT) w32.RECT                { return toTop(disp, 1, 2) }
+func (s split) topOneThirds(disp, _ w32.RECT) w32.RECT { return toTop(disp, s.div-s.mul, s.div) }
+func (s split) topTwoThirds(disp, _ w32.RECT) w32.RECT { return toTop(disp, s.mul, s.div) }
 
-func rightHalf(disp, _ w32.RECT) w32.RECT      { return toRight(disp, 1, 2) }
-func rightOneThirds(disp, _ w32.RECT) w32.RECT { return toRight(disp, 1, 3) }
-func rightTwoThirds(disp, _ w32.RECT) w32.RECT { return toRight(disp, 2, 3) }
+func rightHalf(disp, _ w32.RECT) w32.RECT                { return toRight(disp, 1, 2) }
+func (s split) rightOneThirds(disp, _ w32.RECT) w32.RECT { return toRight(disp, s.div-s.mul, s.div) }
+func (s split) rightTwoThirds(disp, _ w32.RECT) w32.RECT { return toRight(disp, s.mul, s.div) }
 
-func bottomHalf(disp, _ w32.RECT) w32.RECT      { return toBottom(disp, 1, 2) }
-func bottomOneThirds(disp, _ w32.RECT) w32.RECT { return toBottom(disp, 1, 3) }
-func bottomTwoThirds(disp, _ w32.RECT) w32.RECT { return toBottom(disp, 2, 3) }
+func bottomHalf(disp, _ w32.RECT) w32.RECT                { return toBottom(disp, 1, 2) }
+func (s split) bottomOneThirds(disp, _ w32.RECT) w32.RECT { return toBottom(disp, s.div-s.mul, s.div) }
+func (s split) bottomTwoThirds(disp, _ w32.RECT) w32.RECT { return toBottom(disp, s.mul, s.div) }
 
-func middleThirds(disp, _ w32.RECT) w32.RECT {
+// middleThirds is the zone between leftOneThirds and rightOneThirds, so that
+// the three tile the work area whatever the split. With a split under half,
+// where those two overlap, it is their overlap.
+func (s split) middleThirds(disp, cur w32.RECT) w32.RECT {
+	left, right := s.leftOneThirds(disp, cur).Right, s.rightOneThirds(disp, cur).Left
 	return w32.RECT{
-		Left:   disp.Left + disp.Width() - disp.Width()*2/3,
+		Left:   min32(left, right),
 		Top:    disp.Top,
-		Right:  disp.Left + disp.Width()*2/3,
+		Right:  max32(left, right),
 		Bottom: disp.Top + disp.Height()}
 }
+
+// Corners take config.CornerSize percent of the width and height of disp.
+
+func topLeftCorner(disp, _ w32.RECT) w32.RECT {
+	return toTop(toLeft(disp, config.CornerSize, 100), config.CornerSize, 100)
+}
+func topRightCorner(disp, _ w32.RECT) w32.RECT {
+	return toTop(toRight(disp, config.CornerSize, 100), config.CornerSize, 100)
+}
+func bottomLeftCorner(disp, _ w32.RECT) w32.RECT {
+	return toBottom(toLeft(disp, config.CornerSize, 100), config.CornerSize, 100)
+}
+func bottomRightCorner(disp, _ w32.RECT) w32.RECT {
+	return toBottom(toRight(disp, config.CornerSize, 100), config.CornerSize, 100)
+}
+
+func fullWorkArea(disp, _ w32.RECT) w32.RECT { return disp }
+
+// Edge moves keep the size of the window, and move it against an edge of disp.
+// They are used with moveKeepingSize, so they move the visible frame right
+// against the edge, without a gap.
+
+func toLeftEdge(disp, cur w32.RECT) w32.RECT {
+	return w32.RECT{Left: disp.Left, Top: cur.Top, Right: disp.Left + cur.Width(), Bottom: cur.Bottom}
+}
+func toRightEdge(disp, cur w32.RECT) w32.RECT {
+	return w32.RECT{Left: disp.Right - cur.Width(), Top: cur.Top, Right: disp.Right, Bottom: cur.Bottom}
+}
+func toTopEdge(disp, cur w32.RECT) w32.RECT {
+	return w32.RECT{Left: cur.Left, Top: disp.Top, Right: cur.Right, Bottom: disp.Top + cur.Height()}
+}
+func toBottomEdge(disp, cur w32.RECT) w32.RECT {
+	return w32.RECT{Left: cur.Left, Top: disp.Bottom - cur.Height(), Right: cur.Right, Bottom: disp.Bottom}
+}
+
+// zoneFunc is a zone for the split of the window it is used for. See
+// zoneFor for the resizeFunc of a zone for a window.
+type zoneFunc func(s split, disp, cur w32.RECT) w32.RECT
+
+// anySplit makes a zoneFunc of a zone that doesn't depend on the split.
+func anySplit(f resizeFunc) zoneFunc {
+	return func(_ split, disp, cur w32.RECT) w32.RECT { return f(disp, cur) }
+}
+
+// forSplit returns the resizeFunc of the zone for the split.
+func (f zoneFunc) forSplit(s split) resizeFunc {
+	return func(disp, cur w32.RECT) w32.RECT { return f(s, disp, cur) }
+}
+
+// zones are the zones that can be referred to by name in the config.
+var zones = map[string]zoneFunc{
+	"leftHalf":          anySplit(leftHalf),
+	"leftOneThirds":     split.leftOneThirds,
+	"leftTwoThirds":     split.leftTwoThirds,
+	"rightHalf":         anySplit(rightHalf),
+	"rightOneThirds":    split.rightOneThirds,
+	"rightTwoThirds":    split.rightTwoThirds,
+	"topHalf":           anySplit(topHalf),
+	"topOneThirds":      split.topOneThirds,
+	"topTwoThirds":      split.topTwoThirds,
+	"bottomHalf":        anySplit(bottomHalf),
+	"bottomOneThirds":   split.bottomOneThirds,
+	"bottomTwoThirds":   split.bottomTwoThirds,
+	"middleThirds":      split.middleThirds,
+	"topLeftCorner":     anySplit(topLeftCorner),
+	"topRightCorner":    anySplit(topRightCorner),
+	"bottomLeftCorner":  anySplit(bottomLeftCorner),
+	"bottomRightCorner": anySplit(bottomRightCorner),
+	"fullWorkArea":      anySplit(fullWorkArea),
+}
+
+// zoneFor returns the resizeFunc of the named zone for the split of the
+// window.
+func zoneFor(hwnd w32.HWND, name string) resizeFunc {
+	return zones[name].forSplit(splitFor(hwnd))
+}
+
+// zonesFor returns the resizeFuncs of the named zones for the split of the
+// window.
+func zonesFor(hwnd w32.HWND, names []string) []resizeFunc {
+	s := splitFor(hwnd)
+	out := make([]resizeFunc, len(names))
+	for i, name := range names {
+		out[i] = zones[name].forSplit(s)
+	}
+	return out
+}
+
+// complement returns the part of disp not covered by zone, if zone spans disp
+// fully along one axis and is anchored to one of its edges.
+func complement(disp, zone w32.RECT) (w32.RECT, bool) {
+	out := disp
+	switch {
+	case zone.Top == disp.Top && zone.Bottom == disp.Bottom && zone.Left == disp.Left:
+		out.Left = zone.Right
+	case zone.Top == disp.Top && zone.Bottom == disp.Bottom && zone.Right == disp.Right:
+		out.Right = zone.Left
+	case zone.Left == disp.Left && zone.Right == disp.Right && zone.Top == disp.Top:
+		out.Top = zone.Bottom
+	case zone.Left == disp.Left && zone.Right == disp.Right && zone.Bottom == disp.Bottom:
+		out.Bottom = zone.Top
+	default:
+		return w32.RECT{}, false
+	}
+	return out, out.Width() > 0 && out.Height() > 0
+}
+
+// splitRect divides r into n equal rows (or columns), the last one taking
+// any remainder.
+func splitRect(r w32.RECT, n int, columns bool) []w32.RECT {
+	out := make([]w32.RECT, n)
+	for i := range out {
+		cell := r
+		if columns {
+			cell.Left = r.Left + r.Width()*int32(i)/int32(n)
+			cell.Right = r.Left + r.Width()*int32(i+1)/int32(n)
+		} else {
+			cell.Top = r.Top + r.Height()*int32(i)/int32(n)
+			cell.Bottom = r.Top + r.Height()*int32(i+1)/int32(n)
+		}
+		out[i] = cell
+	}
+	return out
+}
+
+// clamp moves r so that it is within bounds, shrinking it if it is larger.
+func clamp(r, bounds w32.RECT) w32.RECT {
+	w, h := r.Width(), r.Height()
+	if w > bounds.Width() {
+		w = bounds.Width()
+	}
+	if h > bounds.Height() {
+		h = bounds.Height()
+	}
+	left, top := r.Left, r.Top
+	if left < bounds.Left {
+		left = bounds.Left
+	} else if left+w > bounds.Right {
+		left = bounds.Right - w
+	}
+	if top < bounds.Top {
+		top = bounds.Top
+	} else if top+h > bounds.Bottom {
+		top = bounds.Bottom - h
+	}
+	return w32.RECT{Left: left, Top: top, Right: left + w, Bottom: top + h}
+}
+
+// currentZone returns the index of the first resizeFunc whose zone the window
+// currently occupies.
+func currentZone(hwnd w32.HWND, funcs []resizeFunc) (int, bool) {
+	_, area, err := workArea(hwnd)
+	if err != nil {
+		fmt.Printf("warn: current zone: %v\n", err)
+		return 0, false
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		fmt.Printf("warn: current zone: %v\n", err)
+		return 0, false
+	}
+	for i, f := range funcs {
+		if matchesZone(frame, withGap(f(area, frame), area, config.Gap), config.ZoneTolerance) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// zoneName returns the name of the zone in zones the window frame is in, on
+// the work area. Zones are tried in the order of their names, and the full
+// work area last.
+func zoneName(hwnd w32.HWND, area, frame w32.RECT) (string, bool) {
+	names := make([]string, 0, len(zones))
+	for name := range zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := splitFor(hwnd)
+	for _, name := range names {
+		if name != "fullWorkArea" && matchesZone(frame, withGap(zones[name](s, area, frame), area, config.Gap), config.ZoneTolerance) {
+			return name, true
+		}
+	}
+	if matchesZone(frame, withGap(area, area, config.Gap), config.ZoneTolerance) {
+		return "fullWorkArea", true
+	}
+	return "", false
+}
+
+// matchesZone reports whether each edge of rect is within tolerance pixels of
+// the same edge of zone. The tolerance absorbs the pixel or two of drift from
+// DPI scaling and apps adjusting their own size.
+func matchesZone(rect, zone w32.RECT, tolerance int32) bool {
+	near := func(a, b int32) bool { return a-b <= tolerance && b-a <= tolerance }
+	return near(rect.Left, zone.Left) && near(rect.Top, zone.Top) && near(rect.Right, zone.Right) && near(rect.Bottom, zone.Bottom)
+}
+
+// flip moves the window to zone b if it is in zone a, and to zone a otherwise.
+func flip(hwnd w32.HWND, a, b resizeFunc) (bool, error) {
+	if i, ok := currentZone(hwnd, []resizeFunc{a, b}); ok && i == 0 {
+		return resize(hwnd, b)
+	}
+	return resize(hwnd, a)
+}
+
+// matchWindow moves and resizes the window to the same position and size as
+// the window that was in the foreground before it.
+func matchWindow(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	src := focusedWindows[1]
+	if hwnd != focusedWindows[0] {
+		src = focusedWindows[0]
+	}
+	if src == 0 || src == hwnd || !w32.IsWindow(src) || !isZonableWindow(src) {
+		fmt.Println("match window: no other window was in the foreground before")
+		return false, nil
+	}
+	frame, err := visibleFrame(src)
+	if err != nil {
+		return false, err
+	}
+	_, area, err := workArea(src)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("> matching window 0x%x %q: %#v\n", src, w32.GetWindowText(src), frame)
+	zone := withoutGap(frame, area, config.Gap)
+	return resize(hwnd, func(_, _ w32.RECT) w32.RECT { return zone })
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gonutz/w32/v2"
+)
+
+func TestMatchesZone(t *testing.T) {
+	const tolerance = 8
+	zone := w32.RECT{Left: 0, Top: 0, Right: 960, Bottom: 1040}
+	edges := []struct {
+		name  string
+		shift func(r *w32.RECT, d int32)
+	}{
+		{"left", func(r *w32.RECT, d int32) { r.Left += d }},
+		{"top", func(r *w32.RECT, d int32) { r.Top += d }},
+		{"right", func(r *w32.RECT, d int32) { r.Right += d }},
+		{"bottom", func(r *w32.RECT, d int32) { r.Bottom += d }},
+	}
+	for _, e := range edges {
+		for _, tc := range []struct {
+			offset int32
+			want   bool
+		}{
+			{0, true},
+			{1, true},
+			{tolerance, true},
+			{tolerance + 1, false},
+			{-1, true},
+			{-tolerance, true},
+			{-tolerance - 1, false},
+		} {
+			t.Run(fmt.Sprintf("%s%+d", e.name, tc.offset), func(t *testing.T) {
+				rect := zone
+				e.shift(&rect, tc.offset)
+				if got := matchesZone(rect, zone, tolerance); got != tc.want {
+					t.Errorf("matchesZone(%#v, %#v, %d) = %v, want %v", rect, zone, tolerance, got, tc.want)
+				}
+			})
+		}
+	}
+}
+
+func TestMatchesZoneZeroTolerance(t *testing.T) {
+	zone := w32.RECT{Left: 960, Top: 0, Right: 1920, Bottom: 1040}
+	if !matchesZone(zone, zone, 0) {
+		t.Errorf("exact match not matched with zero tolerance")
+	}
+	off := zone
+	off.Right++
+	if matchesZone(off, zone, 0) {
+		t.Errorf("%#v matched %#v with zero tolerance", off, zone)
+	}
+}
+
+func TestThirdsTileWithSplit(t *testing.T) {
+	for _, s := range []split{defaultSplit, {60, 100}, {70, 100}, {99, 100}} {
+		for _, width := range []int32{1919, 1920} {
+			work := w32.RECT{Left: 0, Top: 0, Right: width, Bottom: 1040}
+			left, middle, right := s.leftOneThirds(work, work), s.middleThirds(work, work), s.rightOneThirds(work, work)
+			if left.Left != work.Left || left.Right != middle.Left || middle.Right != right.Left || right.Right != work.Right {
+				t.Errorf("split %d/%d, width %d: thirds %d-%d, %d-%d, %d-%d don't tile %d-%d",
+					s.mul, s.div, width, left.Left, left.Right, middle.Left, middle.Right, right.Left, right.Right, work.Left, work.Right)
+			}
+		}
+	}
+}
+
+func TestSplitRounding(t *testing.T) {
+	defer func(r string) { config.SplitRounding = r }(config.SplitRounding)
+	thirds := func(work w32.RECT) (w32.RECT, w32.RECT, w32.RECT) {
+		return defaultSplit.leftOneThirds(work, work), defaultSplit.middleThirds(work, work), defaultSplit.rightOneThirds(work, work)
+	}
+	for _, rounding := range []string{roundEnd, roundStart, roundNearest, roundOut} {
+		config.SplitRounding = rounding
+		for _, width := range []int32{1918, 1919, 1920, 1921} {
+			work := w32.RECT{Left: 100, Top: 0, Right: 100 + width, Bottom: 1040}
+			name := fmt.Sprintf("%s/width=%d", rounding, width)
+
+			left, right := leftHalf(work, work), rightHalf(work, work)
+			if left.Right != right.Left || left.Left != work.Left || right.Right != work.Right {
+				t.Errorf("%s: halves %d-%d and %d-%d don't tile %d-%d", name, left.Left, left.Right, right.Left, right.Right, work.Left, work.Right)
+			}
+			l, m, r := thirds(work)
+			if l.Right != m.Left || m.Right != r.Left || l.Left != work.Left || r.Right != work.Right {
+				t.Errorf("%s: thirds %d-%d, %d-%d, %d-%d don't tile %d-%d", name, l.Left, l.Right, m.Left, m.Right, r.Left, r.Right, work.Left, work.Right)
+			}
+			two, one := defaultSplit.leftTwoThirds(work, work), defaultSplit.rightOneThirds(work, work)
+			if two.Right != one.Left {
+				t.Errorf("%s: leftTwoThirds ends at %d, rightOneThirds starts at %d", name, two.Right, one.Left)
+			}
+
+			diff := right.Width() - left.Width() // leftover pixel of the halves
+			third, middle := l.Width(), m.Width()
+			var ok bool
+			switch rounding {
+			case roundEnd:
+				ok = diff == width%2 && r.Width() >= third && r.Width() >= middle
+			case roundStart:
+				ok = diff == -(width%2) && third >= middle && third >= r.Width()
+			case roundNearest:
+				ok = diff == width%2 || diff == -(width%2)
+			case roundOut:
+				ok = diff == width%2 && third == r.Width() && middle <= third
+			}
+			if !ok {
+				t.Errorf("%s: halves %d and %d, thirds %d, %d and %d", name, left.Width(), right.Width(), third, middle, r.Width())
+			}
+			slack := int32(1)
+			if rounding == roundOut {
+				slack = 2 // the middle third gives up a pixel to each side
+			}
+			if middle > third+slack || middle < third-slack || r.Width() > third+slack || r.Width() < third-slack {
+				t.Errorf("%s: thirds %d, %d and %d differ by more than %dpx", name, third, middle, r.Width(), slack)
+			}
+		}
+	}
+}
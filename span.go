@@ -0,0 +1,85 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+const (
+	spanRight = "right"
+	spanLeft  = "left"
+	spanAll   = "all"
+)
+
+// spanMonitors resizes the window to fill the work areas of its monitor and
+// the one next to it in the spanMonitors direction, or of all monitors, as one
+// window across them. Only the height all of them have in common is used.
+func spanMonitors(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
+	var cur w32.MONITORINFO
+	if !w32.GetMonitorInfo(mon, &cur) {
+		return false, fmt.Errorf("failed to GetMonitorInfo:%d", w32.GetLastError())
+	}
+	span := taskbarWorkArea(mon, cur)
+	n := 1
+	for _, d := range rotationMonitors(mon, hwnd) {
+		var v w32.MONITORINFO
+		if d == mon || !w32.GetMonitorInfo(d, &v) {
+			continue
+		}
+		a, b := cur.RcMonitor, v.RcMonitor
+		next := config.SpanMonitors == spanAll ||
+			(config.SpanMonitors == spanRight && b.Left == a.Right && overlap(a.Top, a.Bottom, b.Top, b.Bottom)) ||
+			(config.SpanMonitors == spanLeft && b.Right == a.Left && overlap(a.Top, a.Bottom, b.Top, b.Bottom))
+		if !next {
+			continue
+		}
+		work := taskbarWorkArea(d, v)
+		span.Left, span.Right = min32(span.Left, work.Left), max32(span.Right, work.Right)
+		span.Top, span.Bottom = max32(span.Top, work.Top), min32(span.Bottom, work.Bottom)
+		n++
+	}
+	if n == 1 {
+		fmt.Printf("span monitors: no monitor to span to the %s\n", config.SpanMonitors)
+		return false, nil
+	}
+	if span.Height() <= 0 {
+		fmt.Println("span monitors: the monitors have no height in common")
+		return false, nil
+	}
+	fmt.Printf("warn: span monitors: window 0x%x %q will span %d monitors, which some apps handle poorly: %#v\n", hwnd, w32.GetWindowText(hwnd), n, span)
+	return resize(hwnd, func(_, _ w32.RECT) w32.RECT { return span })
+}
+
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
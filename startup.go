@@ -0,0 +1,56 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// runStartupActions runs the actions configured with onStartup by name, once
+// the apps launched at login had some time to open their windows. The actions
+// run on the main thread after the message loop has started, one after the
+// other, on whatever window is in the foreground at the time.
+func runStartupActions(hks []HotKey) {
+	if len(config.OnStartup.Actions) == 0 {
+		return
+	}
+	callbacks := make(map[string]func())
+	for _, hk := range hks {
+		callbacks[hk.name] = hk.callback
+	}
+	delay := time.Duration(config.OnStartup.DelayMs) * time.Millisecond
+	fmt.Printf("running %d startup action(s) in %v\n", len(config.OnStartup.Actions), delay)
+	go func() {
+		time.Sleep(delay)
+		runOnMainThread(func() {
+			for _, name := range config.OnStartup.Actions {
+				f, ok := callbacks[name]
+				if !ok {
+					fmt.Printf("warn: onStartup: unknown or disabled action %q\n", name)
+					continue
+				}
+				if w32.GetForegroundWindow() == 0 {
+					fmt.Printf("warn: onStartup: no foreground window for %s, skipping\n", name)
+					continue
+				}
+				fmt.Printf("> startup action: %s\n", name)
+				f()
+			}
+		})
+	}()
+}
@@ -29,7 +29,16 @@ func isZonableWindow(hwnd w32.HWND) bool {
 	if hwnd == 0 {
 		return false
 	}
-	return isStandardWindow(hwnd) && hasNoVisibleOwner(hwnd)
+	return isStandardWindow(hwnd) && hasNoVisibleOwner(hwnd) && !isExcludedApp(hwnd)
+}
+
+// isExcludedApp reports whether the window is of an app excluded in the config.
+func isExcludedApp(hwnd w32.HWND) bool {
+	if len(config.ExcludedApps) == 0 {
+		return false
+	}
+	_, ok := matchApp(config.ExcludedApps, hwnd)
+	return ok
 }
 
 func hasNoVisibleOwner(hwnd w32.HWND) bool {
@@ -0,0 +1,132 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// window classes of the taskbar of the primary monitor and of the others
+const (
+	primaryTaskbarClass   = "Shell_TrayWnd"
+	secondaryTaskbarClass = "Shell_SecondaryTrayWnd"
+)
+
+var (
+	// cachedTaskbars are the rects of the visible taskbars, valid while
+	// taskbarsCached is set. The listener window invalidates them when the
+	// settings or displays change, and they aren't cached without it or when
+	// the taskbars auto-hide.
+	cachedTaskbars []w32.RECT
+	taskbarsCached bool
+)
+
+// taskbarRects returns the rects of the visible taskbars of all monitors.
+func taskbarRects() []w32.RECT {
+	if taskbarsCached {
+		return cachedTaskbars
+	}
+	var out []w32.RECT
+	w32.EnumWindows(func(h w32.HWND) bool {
+		if c, ok := w32.GetClassName(h); !ok || (c != primaryTaskbarClass && c != secondaryTaskbarClass) || !w32.IsWindowVisible(h) {
+			return true
+		}
+		if r := w32.GetWindowRect(h); r != nil {
+			out = append(out, *r)
+		}
+		return true
+	})
+	// auto-hidden taskbars slide in and out without being invalidated
+	var abd w32ex.APPBARDATA
+	cachedTaskbars, taskbarsCached = out, listening && w32ex.SHAppBarMessage(w32ex.ABM_GETSTATE, &abd)&w32ex.ABS_AUTOHIDE == 0
+	return out
+}
+
+// invalidateTaskbars makes taskbarRects look the taskbars up again.
+func invalidateTaskbars() {
+	cachedTaskbars, taskbarsCached = nil, false
+}
+
+// taskbarWorkArea returns the work area of the monitor without the parts
+// covered by the taskbars docked to its edges, if excludeTaskbars is set.
+// RcWork doesn't always leave out the taskbars of secondary monitors.
+func taskbarWorkArea(mon w32.HMONITOR, monInfo w32.MONITORINFO) w32.RECT {
+	work := monInfo.RcWork
+	if !config.ExcludeTaskbars {
+		return work
+	}
+	for _, r := range taskbarRects() {
+		r := r
+		if w32.MonitorFromRect(&r, w32.MONITOR_DEFAULTTONULL) != mon {
+			continue
+		}
+		switch {
+		case r.Left <= work.Left && r.Right >= work.Right && r.Top <= work.Top && r.Bottom > work.Top:
+			work.Top = r.Bottom
+		case r.Left <= work.Left && r.Right >= work.Right && r.Bottom >= work.Bottom && r.Top < work.Bottom:
+			work.Bottom = r.Top
+		case r.Top <= work.Top && r.Bottom >= work.Bottom && r.Left <= work.Left && r.Right > work.Left:
+			work.Left = r.Right
+		case r.Top <= work.Top && r.Bottom >= work.Bottom && r.Right >= work.Right && r.Left < work.Right:
+			work.Right = r.Left
+		}
+	}
+	if work != monInfo.RcWork {
+		fmt.Printf("> work area without the taskbars: %#v (W:%d,H:%d)\n", work, work.Width(), work.Height())
+	}
+	return work
+}
+
+// taskbarEdge returns the edge (ABE_*) of the monitor the taskbar is docked
+// to, if the taskbar is on that monitor.
+func taskbarEdge(mon w32.HMONITOR) (uint32, bool) {
+	var abd w32ex.APPBARDATA
+	if w32ex.SHAppBarMessage(w32ex.ABM_GETTASKBARPOS, &abd) == 0 {
+		fmt.Println("warn: failed to get the taskbar position")
+		return 0, false
+	}
+	if w32.MonitorFromRect(&abd.Rc, w32.MONITOR_DEFAULTTONULL) != mon {
+		return 0, false
+	}
+	return abd.UEdge, true
+}
+
+// flushTaskbarEdge moves the edge of the window rect newPos next to the
+// taskbar so that the visible frame of the window is exactly on the work area
+// edge, if the zone (before the gap is applied) touches that edge. The edges of
+// borders are the widths of the invisible borders of the window.
+func flushTaskbarEdge(mon w32.HMONITOR, work, zone, borders w32.RECT, newPos *w32.RECT) {
+	edge, ok := taskbarEdge(mon)
+	if !ok {
+		return
+	}
+	switch {
+	case edge == w32ex.ABE_LEFT && zone.Left == work.Left:
+		newPos.Left = work.Left - borders.Left
+	case edge == w32ex.ABE_TOP && zone.Top == work.Top:
+		newPos.Top = work.Top - borders.Top
+	case edge == w32ex.ABE_RIGHT && zone.Right == work.Right:
+		newPos.Right = work.Right + borders.Right
+	case edge == w32ex.ABE_BOTTOM && zone.Bottom == work.Bottom:
+		newPos.Bottom = work.Bottom + borders.Bottom
+	default:
+		return
+	}
+	fmt.Printf("> flush with the taskbar (edge %d): %#v\n", edge, *newPos)
+}
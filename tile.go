@@ -0,0 +1,203 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// heroStack snaps the window to the configured hero zone, and tiles the other
+// windows on its monitor in the remaining space.
+func heroStack(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	mon, disp, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	hero := zoneFor(hwnd, config.HeroZone)
+	rest, ok := complement(disp, hero(disp, frame))
+	if !ok {
+		return false, fmt.Errorf("zone %q does not leave a rectangle for the other windows", config.HeroZone)
+	}
+	var others []w32.HWND
+	for _, w := range capWindows("Hero stack", tileableWindows(orderZ, mon), hwnd) {
+		if w.hwnd != hwnd {
+			others = append(others, w.hwnd)
+		}
+	}
+	fmt.Printf("> hero stack: %s + %d window(s) as %s in %#v\n", config.HeroZone, len(others), config.StackArrangement, rest)
+
+	if _, err := resize(hwnd, hero); err != nil {
+		return false, err
+	}
+	return tile(others, splitRect(rest, len(others), config.StackArrangement == stackColumns), rest)
+}
+
+// tileRows tiles the windows on the monitor of the window as full-width rows
+// of equal height, in Z-order from the top.
+func tileRows(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	mon, disp, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	var hwnds []w32.HWND
+	for _, w := range capWindows("Tile rows", tileableWindows(orderZ, mon), hwnd) {
+		hwnds = append(hwnds, w.hwnd)
+	}
+	fmt.Printf("> tiling %d window(s) as rows in %#v\n", len(hwnds), disp)
+	return tile(hwnds, splitRect(disp, len(hwnds), false), disp)
+}
+
+// gather snaps the window and the other windows on its monitor to the
+// configured gather zone, stacked on top of each other with the window on top.
+func gather(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	mon := w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST)
+	var resized bool
+	for _, w := range capWindows("Gather", tileableWindows(orderZ, mon), hwnd) {
+		if w.hwnd == hwnd && config.GatherSkipForeground {
+			continue
+		}
+		ok, err := resize(w.hwnd, zoneFor(w.hwnd, config.GatherZone))
+		if err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", w.hwnd, err)
+		}
+		resized = resized || ok
+	}
+	fmt.Printf("> gathered windows in %s\n", config.GatherZone)
+	return resized, nil
+}
+
+// broadcastZone snaps the topmost window on each of the other monitors to the
+// zone the window is in, so that the same zone is used on every monitor.
+func broadcastZone(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	defer restoreFocus(hwnd)
+	mon, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	name, ok := zoneName(hwnd, area, frame)
+	if !ok {
+		fmt.Printf("broadcast zone: window 0x%x %q is not in a zone\n", hwnd, w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	var ws []listedWindow
+	topmost := make(map[w32.HMONITOR]bool)
+	for _, w := range tileableWindows(orderZ, 0) {
+		m := w32.MonitorFromWindow(w.hwnd, w32.MONITOR_DEFAULTTONEAREST)
+		if m != mon && !topmost[m] {
+			topmost[m] = true
+			ws = append(ws, w)
+		}
+	}
+	fmt.Printf("> broadcasting zone %s to %d monitor(s)\n", name, len(ws))
+	var resized bool
+	for _, w := range capWindows("Broadcast zone", ws, 0) {
+		ok, err := resize(w.hwnd, zoneFor(w.hwnd, name))
+		if err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", w.hwnd, err)
+		}
+		resized = resized || ok
+	}
+	return resized, nil
+}
+
+// tileableWindows returns the windows on the monitor that bulk arrangements
+// tile. Maximized windows are left alone unless tileMaximizedWindows is set.
+func tileableWindows(order windowOrder, mon w32.HMONITOR) []listedWindow {
+	var out []listedWindow
+	for _, w := range zonableWindows(order, mon) {
+		if !config.TileMaximizedWindows && w32ex.IsZoomed(w.hwnd) {
+			fmt.Printf("> tile: skipping maximized window 0x%x %q\n", w.hwnd, w32.GetWindowText(w.hwnd))
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// minSizeSlack is how many pixels larger than its cell a tiled window can be
+// before it is considered to not fit in it.
+const minSizeSlack = 2
+
+// tile resizes each window to the cell at the same index of cells, which
+// divide area.
+//
+// Windows with a minimum size larger than their cell end up overlapping their
+// neighbors. These are logged, and if configured, are floated on top of the
+// other windows at the center of area.
+func tile(hwnds []w32.HWND, cells []w32.RECT, area w32.RECT) (bool, error) {
+	var resized bool
+	for i, h := range hwnds {
+		cell := cells[i]
+		ok, err := resize(h, func(_, _ w32.RECT) w32.RECT { return cell })
+		if err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", h, err)
+		}
+		resized = resized || ok
+	}
+
+	for i, h := range hwnds {
+		frame, err := visibleFrame(h)
+		if err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", h, err)
+		}
+		if frame.Width() <= cells[i].Width()+minSizeSlack && frame.Height() <= cells[i].Height()+minSizeSlack {
+			continue
+		}
+		fmt.Printf("warn: tile: window 0x%x %q is %dx%d, larger than its %dx%d cell; it overlaps its neighbors\n",
+			h, w32.GetWindowText(h), frame.Width(), frame.Height(), cells[i].Width(), cells[i].Height())
+		if config.TileOversizedWindows != oversizedFloat {
+			continue
+		}
+		if _, err := moveKeepingSize(h, func(_, cur w32.RECT) w32.RECT { return center(area, cur) }); err != nil {
+			return resized, fmt.Errorf("window 0x%x: %w", h, err)
+		}
+		if !w32.SetWindowPos(h, w32.HWND_TOP, 0, 0, 0, 0, w32.SWP_NOMOVE|w32.SWP_NOSIZE|w32.SWP_NOACTIVATE) {
+			return resized, fmt.Errorf("failed to SetWindowPos:%d", w32.GetLastError())
+		}
+		fmt.Printf("> tile: floated window 0x%x at the center\n", h)
+	}
+	return resized, nil
+}
@@ -34,7 +34,11 @@ func initTray() {
 func onReady() {
 	systray.SetIcon(icon)
 	systray.SetTitle("RectangleWin")
-	systray.SetTooltip("RectangleWin")
+	if config.Profile != "" && config.Profile != "default" {
+		systray.SetTooltip(fmt.Sprintf("RectangleWin (%s hotkeys)", config.Profile))
+	} else {
+		systray.SetTooltip("RectangleWin")
+	}
 
 	autorun, err := AutoRunEnabled()
 	if err != nil {
@@ -78,13 +82,157 @@ func onReady() {
 
 	systray.AddSeparator()
 
+	mSlots := systray.AddMenuItem("Saved Slots", "Positions saved with Win + Shift + 1-9, recalled with Win + 1-9")
+	var items []*systray.MenuItem
+	for i := 0; i < numSlots; i++ {
+		m := mSlots.AddSubMenuItem("", "")
+		m.Disable()
+		items = append(items, m)
+	}
+	runOnMainThread(func() {
+		slotMenuItems = items
+		updateSlotMenu()
+	})
+
+	mLocked := systray.AddMenuItem("Locked Windows", "Windows kept in their zone with Win + Alt + K, click one to unlock it")
+	var lockedItems []*systray.MenuItem
+	for i := 0; i < maxLockedWindows; i++ {
+		i, m := i, mLocked.AddSubMenuItem("", "Unlock")
+		m.Hide()
+		lockedItems = append(lockedItems, m)
+		go func() {
+			for range m.ClickedCh {
+				runOnMainThread(func() { unlockMenuItem(i) })
+			}
+		}()
+	}
+	runOnMainThread(func() {
+		lockedMenuItems = lockedItems
+		updateLockedMenu()
+	})
+
+	if len(config.Layouts) > 0 {
+		mLayouts := systray.AddMenuItem("Layout", "The layout Win + Alt + J arranges the window and the windows of the other apps of the layout in")
+		var layoutItems []*systray.MenuItem
+		for i, l := range config.Layouts {
+			i, m := i, mLayouts.AddSubMenuItemCheckbox(l.Name, "", false)
+			layoutItems = append(layoutItems, m)
+			go func() {
+				for range m.ClickedCh {
+					runOnMainThread(func() { selectLayout(i) })
+				}
+			}()
+		}
+		runOnMainThread(func() {
+			layoutMenuItems = layoutItems
+			updateLayoutMenu()
+		})
+	}
+
+	mPresentation := systray.AddMenuItemCheckbox("Presentation Mode", "Pin the topmost window at the center of its monitor and pause automatic behaviors", false)
+	runOnMainThread(func() {
+		presentationMenuItem = mPresentation
+		updatePresentationMenu()
+	})
+	go func() {
+		for range mPresentation.ClickedCh {
+			runOnMainThread(func() {
+				// the tray menu has the focus, so pin the window that had it
+				var hwnd w32.HWND
+				if ws := zonableWindows(orderZ, 0); len(ws) > 0 {
+					hwnd = ws[0].hwnd
+				}
+				if _, err := togglePresentationMode(hwnd); err != nil {
+					fmt.Printf("warn: presentation mode: %v\n", err)
+				}
+			})
+		}
+	}()
+
+	mQuiet := systray.AddMenuItemCheckbox("Quiet", "Hide notifications", false)
+	go func() {
+		for range mQuiet.ClickedCh {
+			runOnMainThread(func() {
+				quietMode = !quietMode
+				fmt.Printf("quiet mode=%v\n", quietMode)
+				if quietMode {
+					mQuiet.Check()
+				} else {
+					mQuiet.Uncheck()
+				}
+			})
+		}
+	}()
+
+	mExport := systray.AddMenuItem("Export Settings...", "Save the configuration and saved slots to a file to import on another machine")
+	go func() {
+		for range mExport.ClickedCh {
+			// the dialogs run their own modal loops on this goroutine's thread
+			path, ok := settingsFileDialog(true)
+			if !ok {
+				continue
+			}
+			withSlots := confirm("Also export the saved slots? Their positions are specific to the monitors of this machine.")
+			errc := make(chan error, 1)
+			runOnMainThread(func() { errc <- exportSettings(path, withSlots) })
+			if err := <-errc; err != nil {
+				fmt.Printf("warn: export settings: %v\n", err)
+				showMessageBox(fmt.Sprintf("Failed to export settings: %v", err))
+			}
+		}
+	}()
+
+	mImport := systray.AddMenuItem("Import Settings...", "Replace the configuration with one exported from another machine")
+	go func() {
+		for range mImport.ClickedCh {
+			path, ok := settingsFileDialog(false)
+			if !ok {
+				continue
+			}
+			replace := confirm("Replace the whole configuration? Choose No to only change the settings in the file and keep the others.")
+			errc := make(chan error, 1)
+			runOnMainThread(func() {
+				err := importSettings(path, replace)
+				if err == nil {
+					notify("Settings imported. Restart RectangleWin to apply the configuration.")
+				}
+				errc <- err
+			})
+			if err := <-errc; err != nil {
+				fmt.Printf("warn: import settings: %v\n", err)
+				showMessageBox(fmt.Sprintf("Failed to import settings, nothing was changed: %v", err))
+			}
+		}
+	}()
+
+	mReset := systray.AddMenuItem("Reset Window State", "Forget the sizes and positions RectangleWin tracks for windows")
+	go func() {
+		for range mReset.ClickedCh {
+			runOnMainThread(func() {
+				resetWindowState()
+				fmt.Println("reset window state")
+				notify("Window state has been reset.")
+			})
+		}
+	}()
+
 	mQuit := systray.AddMenuItem("Quit", "")
 	go func() {
-		<-mQuit.ClickedCh
-		fmt.Println("clicked Quit")
-		systray.Quit()
+		for range mQuit.ClickedCh {
+			fmt.Println("clicked Quit")
+			// the message box runs its own modal loop on this goroutine's
+			// thread, so hotkeys keep working while it is shown
+			if config.ConfirmQuit && !confirm("Quit RectangleWin? Its hotkeys will stop working.") {
+				fmt.Println("quit cancelled")
+				continue
+			}
+			systray.Quit()
+			return
+		}
 	}()
 
+	runOnMainThread(hookTrayClicks)
+
 	fmt.Println("tray ready")
 }
 
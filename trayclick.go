@@ -0,0 +1,117 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// trayMenuTimerID is the timer delaying the menu the tray icon opens on a left
+// click, while a double click action is configured, until that click can no
+// longer be the first of a double click.
+const trayMenuTimerID = 0x5257
+
+// trayClicksByName are the tray icon clicks actions can be configured for,
+// by the mouse message the tray icon notifies them with.
+var trayClicksByName = map[string]uint32{
+	"middle": w32.WM_MBUTTONUP,
+	"double": w32.WM_LBUTTONDBLCLK,
+}
+
+var (
+	// trayClickActions are the callbacks of the actions configured for clicks.
+	trayClickActions = make(map[uint32]func())
+
+	systrayWndProc uintptr // window procedure of the tray icon window
+
+	trayMenuWParam    uintptr // of the click the menu is delayed for
+	trayDoubleClicked bool    // the button up of the second click is still to come
+)
+
+// bindTrayClicks looks up the actions configured for clicks on the tray icon,
+// by name.
+func bindTrayClicks(hks []HotKey, clicks map[string]string) {
+	callbacks := make(map[string]func())
+	for _, hk := range hks {
+		callbacks[hk.name] = hk.callback
+	}
+	for click, name := range clicks {
+		f, ok := callbacks[name]
+		if !ok {
+			fmt.Printf("warn: trayClicks: unknown or disabled action %q\n", name)
+			continue
+		}
+		trayClickActions[trayClicksByName[click]] = f // already checked by Config.validate
+	}
+}
+
+// hookTrayClicks subclasses the tray icon window to run the actions configured
+// for clicks on it. It must be called on the main thread once the tray icon
+// exists.
+func hookTrayClicks() {
+	if len(trayClickActions) == 0 {
+		return
+	}
+	hwnd := trayWindow()
+	if hwnd == 0 {
+		fmt.Println("warn: tray clicks: tray icon window not found")
+		return
+	}
+	systrayWndProc = w32.SetWindowLongPtr(hwnd, w32.GWLP_WNDPROC, syscall.NewCallback(trayWndProc))
+	if systrayWndProc == 0 {
+		fmt.Printf("warn: tray clicks: failed to SetWindowLongPtr:%d\n", w32.GetLastError())
+	}
+}
+
+func trayWndProc(hwnd w32.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	if msg == w32.WM_TIMER && wParam == trayMenuTimerID {
+		w32ex.KillTimer(hwnd, trayMenuTimerID)
+		return w32.CallWindowProc(systrayWndProc, hwnd, systrayIconMessage, trayMenuWParam, w32.WM_LBUTTONUP)
+	}
+	if msg == systrayIconMessage {
+		// systray opens the menu on each left click, so with a double click
+		// action it only gets the clicks that weren't followed by another
+		if _, double := trayClickActions[w32.WM_LBUTTONDBLCLK]; double {
+			switch lParam {
+			case w32.WM_LBUTTONUP:
+				if trayDoubleClicked {
+					trayDoubleClicked = false
+					return 0
+				}
+				trayMenuWParam = wParam
+				w32.SetTimer(hwnd, trayMenuTimerID, uint(w32ex.GetDoubleClickTime()), 0)
+				return 0
+			case w32.WM_LBUTTONDBLCLK:
+				w32ex.KillTimer(hwnd, trayMenuTimerID)
+				trayDoubleClicked = true
+			}
+		}
+		if f, ok := trayClickActions[uint32(lParam)]; ok {
+			fmt.Printf("trace: tray click 0x%x\n", lParam)
+			// the taskbar has the focus now, give it back to the window that had it
+			if ws := zonableWindows(orderZ, 0); len(ws) > 0 {
+				setForeground(ws[0].hwnd)
+			}
+			f()
+			return 0
+		}
+	}
+	return w32.CallWindowProc(systrayWndProc, hwnd, msg, wParam, lParam)
+}
@@ -1,5 +1,10 @@
 package w32ex
 
+import (
+	"github.com/gonutz/w32/v2"
+	"golang.org/x/sys/windows"
+)
+
 const (
 	VK_N_A = 0x41
 	VK_N_B = 0x42
@@ -28,3 +33,61 @@ const (
 	VK_N_Y = 0x59
 	VK_N_Z = 0x5A
 )
+
+// https://docs.microsoft.com/en-us/windows/win32/api/shellapi/nf-shellapi-shell_notifyiconw
+const (
+	NIM_MODIFY = 0x00000001
+	NIF_INFO   = 0x00000010
+	NIIF_INFO  = 0x00000001
+)
+
+// https://docs.microsoft.com/en-us/windows/win32/api/shellapi/ns-shellapi-notifyicondataw
+type NOTIFYICONDATA struct {
+	CbSize           uint32
+	HWnd             w32.HWND
+	UID              uint32
+	UFlags           uint32
+	UCallbackMessage uint32
+	HIcon            w32.HICON
+	SzTip            [128]uint16
+	DwState          uint32
+	DwStateMask      uint32
+	SzInfo           [256]uint16
+	UVersion         uint32
+	SzInfoTitle      [64]uint16
+	DwInfoFlags      uint32
+	GuidItem         windows.GUID
+	HBalloonIcon     w32.HICON
+}
+
+// https://docs.microsoft.com/en-us/windows/win32/api/shellapi/nf-shellapi-shappbarmessage
+const (
+	ABM_GETSTATE      = 0x00000004
+	ABM_GETTASKBARPOS = 0x00000005
+
+	ABS_AUTOHIDE = 0x0000001
+
+	ABE_LEFT   = 0
+	ABE_TOP    = 1
+	ABE_RIGHT  = 2
+	ABE_BOTTOM = 3
+)
+
+// https://docs.microsoft.com/en-us/windows/win32/api/shellapi/ns-shellapi-appbardata
+type APPBARDATA struct {
+	CbSize           uint32
+	HWnd             w32.HWND
+	UCallbackMessage uint32
+	UEdge            uint32
+	Rc               w32.RECT
+	LParam           uintptr
+}
+
+// https://docs.microsoft.com/en-us/windows/win32/api/winuser/ns-winuser-msllhookstruct
+type MSLLHOOKSTRUCT struct {
+	Pt          w32.POINT
+	MouseData   uint32
+	Flags       uint32
+	Time        uint32
+	DwExtraInfo uintptr
+}
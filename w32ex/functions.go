@@ -27,7 +27,11 @@ const (
 	GA_ROOTOWNER = 3
 )
 
-var user32 = syscall.NewLazyDLL("user32.dll")
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+	shell32  = syscall.NewLazyDLL("shell32.dll")
+)
 
 func RegisterHotKey(hwnd w32.HWND, id, mod, vk int) bool {
 	r1, _, _ := user32.NewProc("RegisterHotKey").Call(uintptr(hwnd), uintptr(id), uintptr(mod), uintptr(vk))
@@ -66,3 +70,152 @@ func SetProcessDPIAware() bool {
 	r1, _, _ := user32.NewProc("SetProcessDPIAware").Call()
 	return r1 != 0
 }
+
+func IsIconic(hwnd w32.HWND) bool {
+	r1, _, _ := user32.NewProc("IsIconic").Call(uintptr(hwnd))
+	return r1 != 0
+}
+
+func IsZoomed(hwnd w32.HWND) bool {
+	r1, _, _ := user32.NewProc("IsZoomed").Call(uintptr(hwnd))
+	return r1 != 0
+}
+
+// GetMonitorInfoEx is like w32.GetMonitorInfo but also retrieves the device
+// name of the monitor.
+func GetMonitorInfoEx(hMonitor w32.HMONITOR) (w32.MONITORINFOEX, bool) {
+	var v w32.MONITORINFOEX
+	v.CbSize = uint32(unsafe.Sizeof(v))
+	r1, _, _ := user32.NewProc("GetMonitorInfoW").Call(uintptr(hMonitor), uintptr(unsafe.Pointer(&v)))
+	return v, r1 != 0
+}
+
+func GetCurrentThreadId() uint32 {
+	r1, _, _ := kernel32.NewProc("GetCurrentThreadId").Call()
+	return uint32(r1)
+}
+
+func PostThreadMessage(threadID uint32, msg uint32, wParam, lParam uintptr) bool {
+	r1, _, _ := user32.NewProc("PostThreadMessageW").Call(uintptr(threadID), uintptr(msg), wParam, lParam)
+	return r1 != 0
+}
+
+func ShellNotifyIcon(message uint32, data *NOTIFYICONDATA) bool {
+	data.CbSize = uint32(unsafe.Sizeof(*data))
+	r1, _, _ := shell32.NewProc("Shell_NotifyIconW").Call(uintptr(message), uintptr(unsafe.Pointer(data)))
+	return r1 != 0
+}
+
+func SHAppBarMessage(message uint32, data *APPBARDATA) uintptr {
+	data.CbSize = uint32(unsafe.Sizeof(*data))
+	r1, _, _ := shell32.NewProc("SHAppBarMessage").Call(uintptr(message), uintptr(unsafe.Pointer(data)))
+	return r1
+}
+
+func SetWindowRgn(hwnd w32.HWND, rgn w32.HRGN, redraw bool) bool {
+	var r uintptr
+	if redraw {
+		r = 1
+	}
+	r1, _, _ := user32.NewProc("SetWindowRgn").Call(uintptr(hwnd), uintptr(rgn), r)
+	return r1 != 0
+}
+
+func KillTimer(hwnd w32.HWND, id uintptr) bool {
+	r1, _, _ := user32.NewProc("KillTimer").Call(uintptr(hwnd), id)
+	return r1 != 0
+}
+
+func GetDoubleClickTime() uint32 {
+	r1, _, _ := user32.NewProc("GetDoubleClickTime").Call()
+	return uint32(r1)
+}
+
+func AttachThreadInput(idAttach, idAttachTo uint32, attach bool) bool {
+	var a uintptr
+	if attach {
+		a = 1
+	}
+	r1, _, _ := user32.NewProc("AttachThreadInput").Call(uintptr(idAttach), uintptr(idAttachTo), a)
+	return r1 != 0
+}
+
+func BringWindowToTop(hwnd w32.HWND) bool {
+	r1, _, _ := user32.NewProc("BringWindowToTop").Call(uintptr(hwnd))
+	return r1 != 0
+}
+
+var shcore = syscall.NewLazyDLL("shcore.dll")
+
+const MDT_EFFECTIVE_DPI = 0
+
+// GetDpiForMonitor returns the effective DPI of the monitor, or 0 on failure.
+func GetDpiForMonitor(hmonitor w32.HMONITOR) int32 {
+	var dpiX, dpiY uint32
+	r1, _, _ := shcore.NewProc("GetDpiForMonitor").Call(uintptr(hmonitor), MDT_EFFECTIVE_DPI,
+		uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY)))
+	if r1 != 0 { // S_OK
+		return 0
+	}
+	return int32(dpiY)
+}
+
+const PROCESS_DPI_UNAWARE = 0
+
+// GetProcessDpiAwareness returns the DPI awareness (PROCESS_*_DPI_AWARE) of the
+// process.
+func GetProcessDpiAwareness(process w32.HANDLE) (int, bool) {
+	var v int32
+	r1, _, _ := shcore.NewProc("GetProcessDpiAwareness").Call(uintptr(process), uintptr(unsafe.Pointer(&v)))
+	return int(v), r1 == 0 // S_OK
+}
+
+const PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
+
+// QueryFullProcessImageName returns the path of the executable of the process.
+func QueryFullProcessImageName(process w32.HANDLE) (string, bool) {
+	var path [32768]uint16
+	size := uint32(len(path))
+	r1, _, _ := kernel32.NewProc("QueryFullProcessImageNameW").Call(
+		uintptr(process),
+		0,
+		uintptr(unsafe.Pointer(&path[0])),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if r1 == 0 {
+		return "", false
+	}
+	return syscall.UTF16ToString(path[:size]), true
+}
+
+// https://docs.microsoft.com/en-us/windows/win32/winauto/event-constants
+const (
+	EVENT_SYSTEM_FOREGROUND     = 0x0003
+	EVENT_SYSTEM_MINIMIZESTART  = 0x0016
+	EVENT_SYSTEM_MINIMIZEEND    = 0x0017
+	EVENT_OBJECT_SHOW           = 0x8002
+	EVENT_OBJECT_LOCATIONCHANGE = 0x800B
+
+	WINEVENT_OUTOFCONTEXT = 0x0000
+
+	OBJID_WINDOW = 0
+	CHILDID_SELF = 0
+)
+
+// SetWinEventHook installs an out-of-context hook for the range of events,
+// whose callback runs on the calling thread, which must run a message loop.
+func SetWinEventHook(eventMin, eventMax uint32, callback uintptr) w32.HANDLE {
+	return SetWinEventHookForThread(eventMin, eventMax, callback, 0, 0)
+}
+
+// SetWinEventHookForThread is like SetWinEventHook, but only hooks the events
+// of the given process and thread.
+func SetWinEventHookForThread(eventMin, eventMax uint32, callback uintptr, pid, tid uint32) w32.HANDLE {
+	r1, _, _ := user32.NewProc("SetWinEventHook").Call(uintptr(eventMin), uintptr(eventMax), 0, callback, uintptr(pid), uintptr(tid), WINEVENT_OUTOFCONTEXT)
+	return w32.HANDLE(r1)
+}
+
+func UnhookWinEvent(hook w32.HANDLE) bool {
+	r1, _, _ := user32.NewProc("UnhookWinEvent").Call(uintptr(hook))
+	return r1 != 0
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// windowOrder is the order zonableWindows returns windows in.
+type windowOrder string
+
+const (
+	orderZ        windowOrder = "zorder"    // topmost first
+	orderSpatialX windowOrder = "spatial-x" // left to right, then top to bottom
+	orderSpatialY windowOrder = "spatial-y" // top to bottom, then left to right
+	orderRecency  windowOrder = "recency"   // most recently resized by RectangleWin first, then Z-order
+)
+
+// maxRecentWindows is how many windows are remembered for orderRecency.
+const maxRecentWindows = 64
+
+var (
+	// recentWindows are the windows resized by RectangleWin, most recent first.
+	recentWindows []w32.HWND
+
+	// placedWindows are all the windows resized by RectangleWin.
+	placedWindows = make(map[w32.HWND]bool)
+
+	// focusedWindows are the last two zonable windows that were in the
+	// foreground, most recent first.
+	focusedWindows [2]w32.HWND
+)
+
+// listedWindow is a window returned by zonableWindows.
+type listedWindow struct {
+	hwnd  w32.HWND
+	frame w32.RECT // see visibleFrame
+}
+
+// markResized records that the window was just resized by RectangleWin.
+func markResized(hwnd w32.HWND) {
+	lastResized = hwnd
+	if hwnd != edgeCycles.hwnd {
+		edgeCycles.reset() // cycling starts over after moving another window
+	}
+	out := []w32.HWND{hwnd}
+	for _, h := range recentWindows {
+		if h != hwnd && w32.IsWindow(h) && len(out) < maxRecentWindows {
+			out = append(out, h)
+		}
+	}
+	recentWindows = out
+	for h := range placedWindows {
+		if !w32.IsWindow(h) {
+			delete(placedWindows, h)
+		}
+	}
+	placedWindows[hwnd] = true
+}
+
+// trackFocus records that the window came to the foreground.
+func trackFocus(hwnd w32.HWND) {
+	if hwnd == focusedWindows[0] || !isZonableWindow(hwnd) {
+		return
+	}
+	focusedWindows[0], focusedWindows[1] = hwnd, focusedWindows[0]
+}
+
+// zonableWindows returns the zonable windows that are neither minimized nor
+// cloaked, in the given order. If mon is not 0, only the windows on that
+// monitor are returned.
+func zonableWindows(order windowOrder, mon w32.HMONITOR) []listedWindow {
+	var out []listedWindow
+	w32.EnumWindows(func(h w32.HWND) bool {
+		if !isZonableWindow(h) || w32ex.IsIconic(h) || isCloaked(h) {
+			return true
+		}
+		if mon != 0 && w32.MonitorFromWindow(h, w32.MONITOR_DEFAULTTONEAREST) != mon {
+			return true
+		}
+		f, err := visibleFrame(h)
+		if err != nil {
+			fmt.Printf("warn: skipping window 0x%x: %v\n", h, err)
+			return true
+		}
+		out = append(out, listedWindow{hwnd: h, frame: f})
+		return true
+	})
+	sortWindows(out, order, recentWindows) // EnumWindows enumerates in Z-order
+	return out
+}
+
+// sortWindows sorts the windows, listed in Z-order, in the given order. recent
+// are the windows for orderRecency, most recent first. Windows that compare
+// equal keep their Z-order.
+func sortWindows(ws []listedWindow, order windowOrder, recent []w32.HWND) {
+	switch order {
+	case orderZ:
+	case orderSpatialX:
+		sort.SliceStable(ws, func(i, j int) bool {
+			a, b := ws[i].frame, ws[j].frame
+			return a.Left < b.Left || (a.Left == b.Left && a.Top < b.Top)
+		})
+	case orderSpatialY:
+		sort.SliceStable(ws, func(i, j int) bool {
+			a, b := ws[i].frame, ws[j].frame
+			return a.Top < b.Top || (a.Top == b.Top && a.Left < b.Left)
+		})
+	case orderRecency:
+		rank := make(map[w32.HWND]int)
+		for i, h := range recent {
+			rank[h] = i
+		}
+		sort.SliceStable(ws, func(i, j int) bool {
+			ri, okI := rank[ws[i].hwnd]
+			rj, okJ := rank[ws[j].hwnd]
+			return okI && (!okJ || ri < rj)
+		})
+	default:
+		panic(fmt.Sprintf("unknown window order %q", order))
+	}
+}
+
+func isCloaked(hwnd w32.HWND) bool {
+	ok, cloaked := w32.DwmGetWindowAttributeCLOAKED(hwnd)
+	return ok && cloaked != 0
+}
+
+// capWindows returns the windows a bulk operation is about to arrange, up to
+// maxBulkWindows of them, and warns about the ones skipped. The window given
+// as keep is never skipped.
+func capWindows(op string, ws []listedWindow, keep w32.HWND) []listedWindow {
+	max := config.MaxBulkWindows
+	if max == 0 || len(ws) <= max {
+		return ws
+	}
+	var out []listedWindow
+	for i, w := range ws {
+		if w.hwnd == keep || len(out) < max-1 || (len(out) < max && !containsWindow(ws[i+1:], keep)) {
+			out = append(out, w)
+		}
+	}
+	skipped := len(ws) - len(out)
+	fmt.Printf("warn: %s: %d windows, more than maxBulkWindows=%d, skipping %d\n", op, len(ws), max, skipped)
+	notify(fmt.Sprintf("%s: skipped %d of %d windows (maxBulkWindows is %d).", op, skipped, len(ws), max))
+	return out
+}
+
+// restoreFocus gives the focus back to the window, if it lost it and is still
+// visible, and restoreFocus is set. Bulk operations defer it with the
+// foreground window they started with, in case an app activates its window
+// when it is moved.
+func restoreFocus(hwnd w32.HWND) {
+	if !config.RestoreFocus || hwnd == 0 || w32.GetForegroundWindow() == hwnd || !w32.IsWindow(hwnd) || !w32.IsWindowVisible(hwnd) {
+		return
+	}
+	fmt.Printf("> restoring focus to window 0x%x %q\n", hwnd, w32.GetWindowText(hwnd))
+	setForeground(hwnd)
+}
+
+func containsWindow(ws []listedWindow, hwnd w32.HWND) bool {
+	for _, w := range ws {
+		if w.hwnd == hwnd {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// at is a listed window with its frame at x,y.
+func at(hwnd w32.HWND, x, y int32) listedWindow {
+	return listedWindow{hwnd: hwnd, frame: w32.RECT{Left: x, Top: y, Right: x + 100, Bottom: y + 100}}
+}
+
+func TestSortWindows(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		order  windowOrder
+		ws     []listedWindow // in Z-order
+		recent []w32.HWND
+		want   []w32.HWND
+	}{
+		{
+			name:  "zorder keeps the order",
+			order: orderZ,
+			ws:    []listedWindow{at(1, 500, 0), at(2, 0, 0), at(3, 0, 500)},
+			want:  []w32.HWND{1, 2, 3},
+		},
+		{
+			name:  "spatial-x",
+			order: orderSpatialX,
+			ws:    []listedWindow{at(1, 500, 0), at(2, 0, 500), at(3, 250, 0)},
+			want:  []w32.HWND{2, 3, 1},
+		},
+		{
+			name:  "spatial-x breaks ties top to bottom",
+			order: orderSpatialX,
+			ws:    []listedWindow{at(1, 0, 500), at(2, 500, 0), at(3, 0, 0)},
+			want:  []w32.HWND{3, 1, 2},
+		},
+		{
+			name:  "spatial-x keeps the Z-order of windows at the same spot",
+			order: orderSpatialX,
+			ws:    []listedWindow{at(1, 500, 0), at(2, 0, 0), at(3, 0, 0)},
+			want:  []w32.HWND{2, 3, 1},
+		},
+		{
+			name:  "spatial-y",
+			order: orderSpatialY,
+			ws:    []listedWindow{at(1, 0, 500), at(2, 500, 0), at(3, 0, 250)},
+			want:  []w32.HWND{2, 3, 1},
+		},
+		{
+			name:  "spatial-y breaks ties left to right",
+			order: orderSpatialY,
+			ws:    []listedWindow{at(1, 500, 0), at(2, 0, 500), at(3, 0, 0)},
+			want:  []w32.HWND{3, 1, 2},
+		},
+		{
+			name:  "spatial-y keeps the Z-order of windows at the same spot",
+			order: orderSpatialY,
+			ws:    []listedWindow{at(1, 0, 500), at(2, 0, 0), at(3, 0, 0)},
+			want:  []w32.HWND{2, 3, 1},
+		},
+		{
+			name:   "recency",
+			order:  orderRecency,
+			ws:     []listedWindow{at(1, 0, 0), at(2, 0, 0), at(3, 0, 0)},
+			recent: []w32.HWND{3, 1, 2},
+			want:   []w32.HWND{3, 1, 2},
+		},
+		{
+			name:   "recency puts unranked windows last in Z-order",
+			order:  orderRecency,
+			ws:     []listedWindow{at(1, 0, 0), at(2, 0, 0), at(3, 0, 0), at(4, 0, 0)},
+			recent: []w32.HWND{3, 9},
+			want:   []w32.HWND{3, 1, 2, 4},
+		},
+		{
+			name:  "recency with no ranked windows keeps the Z-order",
+			order: orderRecency,
+			ws:    []listedWindow{at(1, 0, 0), at(2, 0, 0)},
+			want:  []w32.HWND{1, 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sortWindows(tc.ws, tc.order, tc.recent)
+			got := make([]w32.HWND, len(tc.ws))
+			for i, w := range tc.ws {
+				got[i] = w.hwnd
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
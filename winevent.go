@@ -0,0 +1,60 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// winEventHandlers are called on the main thread with the window of the
+// events they are registered for.
+var winEventHandlers = map[uint32]func(hwnd w32.HWND){
+	w32ex.EVENT_SYSTEM_FOREGROUND: func(hwnd w32.HWND) {
+		trackFocus(hwnd)
+		onForegroundChanged(hwnd)
+		onFocusModeForeground(hwnd)
+	},
+	w32ex.EVENT_SYSTEM_MINIMIZESTART: onMinimizeStart,
+	w32ex.EVENT_SYSTEM_MINIMIZEEND:   onMinimizeEnd,
+	w32ex.EVENT_OBJECT_SHOW:          onWindowShown,
+}
+
+var winEventCallback = syscall.NewCallback(func(hook, event, hwnd, idObject, idChild, thread, time uintptr) uintptr {
+	if int32(idObject) != w32ex.OBJID_WINDOW || int32(idChild) != w32ex.CHILDID_SELF || hwnd == 0 {
+		return 0
+	}
+	if f, ok := winEventHandlers[uint32(event)]; ok {
+		f(w32.HWND(hwnd))
+	}
+	return 0
+})
+
+// installWinEventHooks hooks the events of winEventHandlers. It must be called
+// from the thread running msgLoop, which the hooks are delivered to.
+func installWinEventHooks() {
+	for event := range winEventHandlers {
+		if event == w32ex.EVENT_OBJECT_SHOW && !config.NewWindowsUnderCursor {
+			continue // delivered for every object shown on the system, only needed for that
+		}
+		if w32ex.SetWinEventHook(event, event, winEventCallback) == 0 {
+			fmt.Printf("warn: failed to SetWinEventHook(0x%x):%d\n", event, w32.GetLastError())
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+)
+
+// cycleNames are the action names of the zone cycles, in the order of their
+// zones in edgeZones.
+var cycleNames = []string{"cycleLeft", "cycleRight", "cycleTop", "cycleBottom", "cycleThirds", "cycleCorners"}
+
+// edgeZones are the names of the zones each cycle goes through by default.
+var edgeZones = [][]string{
+	{"leftHalf", "leftTwoThirds", "leftOneThirds"},
+	{"rightHalf", "rightTwoThirds", "rightOneThirds"},
+	{"topHalf", "topTwoThirds", "topOneThirds"},
+	{"bottomHalf", "bottomTwoThirds", "bottomOneThirds"},
+	{"leftOneThirds", "middleThirds", "rightOneThirds"},
+	{"topLeftCorner", "topRightCorner", "bottomRightCorner", "bottomLeftCorner"},
+}
+
+// cycleZones returns the zones that the cycle i goes through for the window.
+func cycleZones(hwnd w32.HWND, i int) []resizeFunc {
+	return zonesFor(hwnd, monitorZoneSet(hwnd, i, edgeZones[i]))
+}
+
+// cycleZone returns the index of the zone of the cycle i the window is in.
+func cycleZone(hwnd w32.HWND, i int) (int, bool) {
+	return currentZone(hwnd, cycleZones(hwnd, i))
+}
+
+// monitorZoneSet returns the names of the zones that the cycle i goes through
+// for the window, from monitorZoneSets for its monitor if configured there,
+// and the default zones otherwise.
+func monitorZoneSet(hwnd w32.HWND, i int, defaults []string) []string {
+	device := monitorDeviceName(w32.MonitorFromWindow(hwnd, w32.MONITOR_DEFAULTTONEAREST))
+	names, ok := config.MonitorZoneSets[device][cycleNames[i]] // already checked by Config.validate
+	if !ok {
+		return defaults
+	}
+	fmt.Printf("> %s on %s: %v\n", cycleNames[i], device, names)
+	return names
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonutz/w32/v2"
+
+	"github.com/ahmetb/RectangleWin/w32ex"
+)
+
+// zoomedWindow is a window maximized by toggleZoom.
+type zoomedWindow struct {
+	zone string     // name of the zone the window was in, or "" if none
+	back resizeFunc // puts the window back in that zone
+}
+
+// zoomedWindows are the windows maximized by toggleZoom.
+var zoomedWindows = make(map[w32.HWND]zoomedWindow)
+
+// toggleZoom maximizes the window, remembering the zone it is in, or puts a
+// window it maximized back in that zone. The zone is computed again for the
+// current work area of the window, so it fits even if the window was moved to
+// another monitor since. A window that isn't in a zone is put back at the same
+// position relative to the work area.
+func toggleZoom(hwnd w32.HWND) (bool, error) {
+	if !isZonableWindow(hwnd) {
+		fmt.Printf("warn: non-zonable window: %s\n", w32.GetWindowText(hwnd))
+		return false, nil
+	}
+	for h := range zoomedWindows {
+		if !w32.IsWindow(h) {
+			delete(zoomedWindows, h)
+		}
+	}
+	_, area, err := workArea(hwnd)
+	if err != nil {
+		return false, err
+	}
+	frame, err := visibleFrame(hwnd)
+	if err != nil {
+		return false, err
+	}
+	if z, ok := zoomedWindows[hwnd]; ok && (w32ex.IsZoomed(hwnd) || matchesZone(frame, withGap(area, area, config.Gap), config.ZoneTolerance)) {
+		delete(zoomedWindows, hwnd)
+		fmt.Printf("> zoom: back to zone %q\n", z.zone)
+		return resize(hwnd, z.back)
+	}
+
+	zone := withoutGap(frame, area, config.Gap)
+	z := zoomedWindow{back: func(disp, _ w32.RECT) w32.RECT { return mapRect(zone, area, disp) }}
+	if name, ok := zoneName(hwnd, area, frame); ok && name != "fullWorkArea" {
+		z = zoomedWindow{zone: name, back: zoneFor(hwnd, name)}
+	}
+	fmt.Printf("> zoom: maximizing from zone %q\n", z.zone)
+	if err := maximizeWindow(hwnd); err != nil {
+		return false, err
+	}
+	zoomedWindows[hwnd] = z
+	return true, nil
+}